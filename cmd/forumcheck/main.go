@@ -0,0 +1,119 @@
+// Command forumcheck recomputes the denormalized forum and thread counters,
+// forum users and post paths from the source tables and prints where they
+// drifted. With the repair action it also rewrites the drifted rows.
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/viewsharp/technopark-forum/internal/config"
+	"github.com/viewsharp/technopark-forum/internal/db"
+	"github.com/viewsharp/technopark-forum/internal/txmanager"
+	"github.com/viewsharp/technopark-forum/internal/usecase/check"
+)
+
+const usage = `usage:
+  forumcheck [check] [flags]    report drift, exit with 1 if there is any
+  forumcheck repair [flags]     report and repair drift in one transaction
+
+Flags and environment variables are the same as for server serve. Servers
+running against the database keep cached threads up to cache.ttl.`
+
+func main() {
+	err := run(os.Args[1:])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string) error {
+	action := "check"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		action, args = args[0], args[1:]
+	}
+	if action != "check" && action != "repair" {
+		return fmt.Errorf("unknown action %q\n%s", action, usage)
+	}
+
+	cfg, err := config.Load(args)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	dbpool, err := pgxpool.New(ctx, cfg.Postgres.DSN)
+	if err != nil {
+		return fmt.Errorf("unable to create connection pool: %w", err)
+	}
+	defer dbpool.Close()
+
+	queries := db.New(dbpool)
+	usecase := &check.Usecase{Tx: txmanager.New(dbpool, queries)}
+
+	report, err := usecase.Run(ctx, action == "repair")
+	if err != nil {
+		return err
+	}
+
+	printReport(report)
+	if !report.Empty() && !report.Repaired {
+		return fmt.Errorf("found drift in %d forums, %d threads, %d post paths and %d post votes",
+			len(report.Forums), len(report.Threads), len(report.Posts), len(report.PostVotes))
+	}
+	return nil
+}
+
+func printReport(report *check.Report) {
+	if report.Empty() {
+		fmt.Println("no drift")
+		return
+	}
+
+	for _, forum := range report.Forums {
+		fmt.Printf("forum %s:", forum.Slug)
+		if forum.Posts != forum.ActualPosts {
+			fmt.Printf(" posts %d, actual %d;", forum.Posts, forum.ActualPosts)
+		}
+		if forum.Threads != forum.ActualThreads {
+			fmt.Printf(" threads %d, actual %d;", forum.Threads, forum.ActualThreads)
+		}
+		if forum.MissingUsers > 0 || forum.ExtraUsers > 0 {
+			fmt.Printf(" users %d missing, %d extra;", forum.MissingUsers, forum.ExtraUsers)
+		}
+		fmt.Println()
+	}
+	for _, thread := range report.Threads {
+		fmt.Printf("thread %d in %s: votes %d, actual %d\n", thread.Id, thread.Forum, thread.Votes, thread.ActualVotes)
+	}
+	for _, post := range report.Posts {
+		fmt.Printf("post %d in thread %d:", post.Id, post.Thread)
+		if !slices.Equal(post.Path, post.ExpectedPath) {
+			fmt.Printf(" path %v, expected %v;", post.Path, post.ExpectedPath)
+		}
+		if post.RootId != post.ExpectedRootId {
+			fmt.Printf(" root %d, expected %d;", post.RootId, post.ExpectedRootId)
+		}
+		if !slices.Equal(post.SortKey, post.ExpectedSortKey) {
+			fmt.Printf(" sort key %v, expected %v;", post.SortKey, post.ExpectedSortKey)
+		}
+		fmt.Println()
+	}
+	for _, post := range report.PostVotes {
+		fmt.Printf("post %d in thread %d: votes %d, actual %d\n", post.Id, post.Thread, post.Votes, post.ActualVotes)
+	}
+
+	if report.Repaired {
+		fmt.Println("repaired")
+	}
+}
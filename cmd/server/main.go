@@ -3,48 +3,198 @@ package main
 import (
 	"context"
 	"fmt"
-	"log"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/valyala/fasthttp"
 	"go.uber.org/zap"
 
+	"github.com/viewsharp/technopark-forum/internal/cache"
+	"github.com/viewsharp/technopark-forum/internal/config"
 	"github.com/viewsharp/technopark-forum/internal/db"
+	"github.com/viewsharp/technopark-forum/internal/dbrouter"
 	"github.com/viewsharp/technopark-forum/internal/handlers"
+	"github.com/viewsharp/technopark-forum/internal/metrics"
+	"github.com/viewsharp/technopark-forum/internal/middleware"
+	"github.com/viewsharp/technopark-forum/internal/qlogger"
 	"github.com/viewsharp/technopark-forum/internal/router"
+	"github.com/viewsharp/technopark-forum/internal/txmanager"
 )
 
-var ServerAddr = os.Getenv("SERVER_ADDR")
-var PostgresDSN = os.Getenv("POSTGRES_DSN")
+const usage = `usage:
+  server [serve] [flags]            run the HTTP server
+  server migrate up [flags]         apply pending migrations
+  server migrate down [flags]       roll back the latest migration
+  server migrate status [flags]     list migrations and whether they are applied`
 
 func main() {
-	logger, _ := zap.NewProduction()
-	defer logger.Sync()
+	command, args := "serve", os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		command, args = args[0], args[1:]
+	}
 
-	dbpool, err := pgxpool.New(context.Background(), PostgresDSN)
+	var err error
+	switch command {
+	case "serve":
+		err = serve(args)
+	case "migrate":
+		err = migrate(args)
+	default:
+		err = fmt.Errorf("unknown command %q\n%s", command, usage)
+	}
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Unable to create connection pool: %v\n", err)
+		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
+}
+
+func serve(args []string) error {
+	cfg, err := config.Load(args)
+	if err != nil {
+		return err
+	}
+
+	logger, err := newLogger(cfg.Log)
+	if err != nil {
+		return fmt.Errorf("create logger: %w", err)
+	}
+	defer logger.Sync()
+
+	dbpool, err := newPool(context.Background(), cfg.Postgres.DSN, cfg.Postgres.MaxConns)
+	if err != nil {
+		return err
+	}
 	defer dbpool.Close()
 
-	querier := db.New(dbpool)
-
-	usecaseSet := handlers.NewUsecaseSet(dbpool, querier)
-	serverRouter := router.New(usecaseSet)
-
-	log.Printf("starting server at: %s\n", ServerAddr)
-	log.Fatal(fasthttp.ListenAndServe(ServerAddr, func(ctx *fasthttp.RequestCtx) {
-		t := time.Now()
-		serverRouter.Handler(ctx)
-		logger.Info(
-			"handled",
-			zap.Int("status", ctx.Response.Header.StatusCode()),
-			zap.ByteString("method", ctx.Method()),
-			zap.Duration("duration", time.Since(t)),
-			zap.ByteString("uri", ctx.Request.Header.RequestURI()),
-		)
-	}))
+	replicaPools := make([]*pgxpool.Pool, 0, len(cfg.Postgres.ReplicaDSNs))
+	for i, dsn := range cfg.Postgres.ReplicaDSNs {
+		replicaPool, err := newPool(context.Background(), dsn, cfg.Postgres.MaxConns)
+		if err != nil {
+			return fmt.Errorf("replica %d: %w", i+1, err)
+		}
+		defer replicaPool.Close()
+		replicaPools = append(replicaPools, replicaPool)
+	}
+
+	if cfg.Postgres.AutoMigrate {
+		err = migrateUp(context.Background(), dbpool, logger)
+		if err != nil {
+			return fmt.Errorf("auto-migrate: %w", err)
+		}
+	}
+
+	poolStats := metrics.Register(&metrics.PoolStats{})
+	poolStats.Add("primary", dbpool)
+	for i, replicaPool := range replicaPools {
+		poolStats.Add(fmt.Sprintf("replica%d", i+1), replicaPool)
+	}
+
+	var primary qlogger.DB = dbpool
+	if cfg.Log.Queries {
+		primary = qlogger.NewQueryLogger(dbpool, logger)
+	}
+	replicas := make([]db.DBTX, 0, len(replicaPools))
+	for _, replicaPool := range replicaPools {
+		var replica db.DBTX = replicaPool
+		if cfg.Log.Queries {
+			replica = qlogger.NewQueryLogger(replicaPool, logger)
+		}
+		replicas = append(replicas, replica)
+	}
+
+	dbtx := dbrouter.New(primary, replicas...)
+	querier := db.New(dbtx)
+	txManager := txmanager.New(dbtx, querier)
+
+	entities := cache.NewEntities(querier, cfg.Cache.Size, cfg.Cache.TTL)
+
+	usecaseSet := handlers.NewUsecaseSet(querier, entities, txManager, cfg)
+	serverRouter := router.New(usecaseSet, cfg)
+	serverRouter.Use(
+		middleware.RequestID,
+		middleware.AccessLog(logger),
+		middleware.Metrics,
+		middleware.Recovery(logger),
+		middleware.ReplicaReads,
+	)
+
+	server := &fasthttp.Server{
+		Handler:         serverRouter.Handler,
+		ReadTimeout:     cfg.Server.ReadTimeout,
+		WriteTimeout:    cfg.Server.WriteTimeout,
+		IdleTimeout:     cfg.Server.IdleTimeout,
+		CloseOnShutdown: true,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	serveErr := make(chan error, 1)
+	go func() {
+		logger.Info("starting server", zap.String("addr", cfg.Server.Addr))
+		serveErr <- server.ListenAndServe(cfg.Server.Addr)
+	}()
+
+	select {
+	case err = <-serveErr:
+		return fmt.Errorf("listen and serve: %w", err)
+	case <-ctx.Done():
+		stop()
+	}
+
+	logger.Info("shutting down", zap.Duration("timeout", cfg.Server.ShutdownTimeout))
+	err = shutdown(server, cfg.Server.ShutdownTimeout)
+	if err != nil {
+		return fmt.Errorf("shutdown: %w", err)
+	}
+	logger.Info("server stopped")
+
+	return nil
+}
+
+func newPool(ctx context.Context, dsn string, maxConns int32) (*pgxpool.Pool, error) {
+	poolConfig, err := pgxpool.ParseConfig(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("parse postgres dsn: %w", err)
+	}
+	if maxConns > 0 {
+		poolConfig.MaxConns = maxConns
+	}
+
+	dbpool, err := pgxpool.NewWithConfig(ctx, poolConfig)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create connection pool: %w", err)
+	}
+	return dbpool, nil
+}
+
+// shutdown stops accepting new connections and waits for in-flight requests
+// to complete, giving up once timeout elapses.
+func shutdown(server *fasthttp.Server, timeout time.Duration) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- server.Shutdown()
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(timeout):
+		return fmt.Errorf("in-flight requests not drained after %s", timeout)
+	}
+}
+
+func newLogger(cfg config.Log) (*zap.Logger, error) {
+	level, err := zap.ParseAtomicLevel(cfg.Level)
+	if err != nil {
+		return nil, err
+	}
+
+	zapConfig := zap.NewProductionConfig()
+	zapConfig.Level = level
+	return zapConfig.Build()
 }
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/pressly/goose/v3"
+	"go.uber.org/zap"
+
+	"github.com/viewsharp/technopark-forum/internal/config"
+	"github.com/viewsharp/technopark-forum/internal/db/migrations"
+)
+
+// migrate runs one of the migrate subcommands against the configured
+// database. The action comes before the usual server flags.
+func migrate(args []string) error {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "-help" {
+		return errors.New(usage)
+	}
+	action, args := args[0], args[1:]
+	switch action {
+	case "up", "down", "status":
+	default:
+		return fmt.Errorf("unknown migrate action %q\n%s", action, usage)
+	}
+
+	cfg, err := config.Load(args)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	dbpool, err := newPool(ctx, cfg.Postgres.DSN, cfg.Postgres.MaxConns)
+	if err != nil {
+		return err
+	}
+	defer dbpool.Close()
+
+	sqlDB := stdlib.OpenDBFromPool(dbpool)
+	defer sqlDB.Close()
+
+	provider, err := migrations.NewProvider(sqlDB)
+	if err != nil {
+		return fmt.Errorf("load migrations: %w", err)
+	}
+
+	switch action {
+	case "up":
+		results, err := provider.Up(ctx)
+		if err != nil {
+			return fmt.Errorf("migrate up: %w", err)
+		}
+		if len(results) == 0 {
+			fmt.Println("no pending migrations")
+		}
+		for _, result := range results {
+			fmt.Println(result)
+		}
+	case "down":
+		result, err := provider.Down(ctx)
+		if err != nil {
+			return fmt.Errorf("migrate down: %w", err)
+		}
+		fmt.Println(result)
+	case "status":
+		statuses, err := provider.Status(ctx)
+		if err != nil {
+			return fmt.Errorf("migrate status: %w", err)
+		}
+		for _, status := range statuses {
+			appliedAt := "-"
+			if status.State == goose.StateApplied {
+				appliedAt = status.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Printf("%-8s %-25s %s\n", status.State, appliedAt, filepath.Base(status.Source.Path))
+		}
+	}
+
+	return nil
+}
+
+// migrateUp applies pending migrations before the server starts accepting
+// requests.
+func migrateUp(ctx context.Context, dbpool *pgxpool.Pool, logger *zap.Logger) error {
+	sqlDB := stdlib.OpenDBFromPool(dbpool)
+	defer sqlDB.Close()
+
+	provider, err := migrations.NewProvider(sqlDB)
+	if err != nil {
+		return fmt.Errorf("load migrations: %w", err)
+	}
+
+	results, err := provider.Up(ctx)
+	if err != nil {
+		return err
+	}
+	for _, result := range results {
+		logger.Info("applied migration",
+			zap.String("migration", filepath.Base(result.Source.Path)),
+			zap.Duration("duration", result.Duration),
+		)
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package cache
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/viewsharp/technopark-forum/internal/db"
+)
+
+// Entities caches the rows behind the slug, id and nickname lookups every
+// write path starts with. Slugs and nicknames are case insensitive, so they
+// are keyed in lower case.
+//
+// Only what never changes after a row is created is cached, so entries need
+// no invalidation: nothing written later by this or another instance, or by
+// a forumcheck repair, can make them stale, and a fill from a lagging
+// replica is as good as one from the primary. Forum rows carry counters
+// that are stale by design and must be read with db.Queries.GetForumBySlug;
+// the title, message and votes of threads and the profiles of users are
+// not cached at all.
+type Entities struct {
+	queries *db.Queries
+
+	forums  *LRU[string, db.Forum]
+	threads *LRU[int32, Thread]
+	// threadIDs maps slugs to ids
+	threadIDs *LRU[string, int32]
+	users     *LRU[string, User]
+}
+
+// Thread identifies a thread and the forum it belongs to.
+type Thread struct {
+	ID        int32
+	Slug      pgtype.Text
+	ForumSlug string
+}
+
+func threadOf(t db.Thread) Thread {
+	return Thread{ID: t.ID, Slug: t.Slug, ForumSlug: t.ForumSlug}
+}
+
+// User identifies a user by the id and the nickname in its canonical case.
+type User struct {
+	ID       int32
+	Nickname string
+}
+
+func NewEntities(queries *db.Queries, size int, ttl time.Duration) *Entities {
+	return &Entities{
+		queries:   queries,
+		forums:    NewLRU[string, db.Forum]("forum", size, ttl),
+		threads:   NewLRU[int32, Thread]("thread", size, ttl),
+		threadIDs: NewLRU[string, int32]("thread_slug", size, ttl),
+		users:     NewLRU[string, User]("user", size, ttl),
+	}
+}
+
+// ForumBySlug returns the forum with possibly stale counters.
+func (e *Entities) ForumBySlug(ctx context.Context, slug string) (db.Forum, error) {
+	key := strings.ToLower(slug)
+	if forum, ok := e.forums.Get(key); ok {
+		return forum, nil
+	}
+
+	forum, err := e.queries.GetForumBySlug(ctx, slug)
+	if err != nil {
+		return db.Forum{}, err
+	}
+	e.forums.Set(key, forum)
+	return forum, nil
+}
+
+func (e *Entities) ThreadByID(ctx context.Context, id int32) (Thread, error) {
+	if thread, ok := e.threads.Get(id); ok {
+		return thread, nil
+	}
+
+	row, err := e.queries.GetThreadByID(ctx, id)
+	if err != nil {
+		return Thread{}, err
+	}
+	thread := threadOf(row)
+	e.threads.Set(id, thread)
+	return thread, nil
+}
+
+func (e *Entities) ThreadBySlug(ctx context.Context, slug string) (Thread, error) {
+	key := strings.ToLower(slug)
+	if id, ok := e.threadIDs.Get(key); ok {
+		if thread, ok := e.threads.Get(id); ok {
+			return thread, nil
+		}
+	}
+
+	row, err := e.queries.GetThreadBySlug(ctx, pgtype.Text{String: slug, Valid: true})
+	if err != nil {
+		return Thread{}, err
+	}
+	thread := threadOf(row)
+	e.threadIDs.Set(key, thread.ID)
+	e.threads.Set(thread.ID, thread)
+	return thread, nil
+}
+
+func (e *Entities) UserByNickname(ctx context.Context, nickname string) (User, error) {
+	key := strings.ToLower(nickname)
+	if user, ok := e.users.Get(key); ok {
+		return user, nil
+	}
+
+	row, err := e.queries.GetUserByNickname(ctx, nickname)
+	if err != nil {
+		return User{}, err
+	}
+	user := User{ID: row.ID, Nickname: row.Nickname}
+	e.users.Set(key, user)
+	return user, nil
+}
+
+// Purge drops everything, for when the database is cleared.
+func (e *Entities) Purge() {
+	e.forums.Purge()
+	e.threads.Purge()
+	e.threadIDs.Purge()
+	e.users.Purge()
+}
@@ -0,0 +1,132 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/viewsharp/technopark-forum/internal/metrics"
+)
+
+var (
+	hitsTotal = metrics.Register(metrics.NewCounterVec(
+		"cache_hits_total",
+		"Number of lookups answered from the cache.",
+		"cache",
+	))
+	missesTotal = metrics.Register(metrics.NewCounterVec(
+		"cache_misses_total",
+		"Number of lookups that had to query the database.",
+		"cache",
+	))
+	evictionsTotal = metrics.Register(metrics.NewCounterVec(
+		"cache_evictions_total",
+		"Number of entries dropped to stay within the size limit.",
+		"cache",
+	))
+)
+
+// LRU is a size bounded map whose entries expire ttl after being stored. It
+// is safe for concurrent use. An LRU with a size below one stores nothing.
+type LRU[K comparable, V any] struct {
+	size int
+	ttl  time.Duration
+
+	hits      *metrics.Counter
+	misses    *metrics.Counter
+	evictions *metrics.Counter
+
+	mu      sync.Mutex
+	entries map[K]*list.Element
+	// order holds the most recently used entry at the front
+	order *list.List
+}
+
+type entry[K comparable, V any] struct {
+	key       K
+	value     V
+	expiresAt time.Time
+}
+
+// NewLRU returns an LRU reporting its metrics under name.
+func NewLRU[K comparable, V any](name string, size int, ttl time.Duration) *LRU[K, V] {
+	return &LRU[K, V]{
+		size:      size,
+		ttl:       ttl,
+		hits:      hitsTotal.With(name),
+		misses:    missesTotal.With(name),
+		evictions: evictionsTotal.With(name),
+		entries:   make(map[K]*list.Element),
+		order:     list.New(),
+	}
+}
+
+func (c *LRU[K, V]) Get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		c.misses.Inc()
+		var zero V
+		return zero, false
+	}
+
+	e := element.Value.(*entry[K, V])
+	if time.Now().After(e.expiresAt) {
+		c.remove(element)
+		c.misses.Inc()
+		var zero V
+		return zero, false
+	}
+
+	c.order.MoveToFront(element)
+	c.hits.Inc()
+	return e.value, true
+}
+
+func (c *LRU[K, V]) Set(key K, value V) {
+	if c.size < 1 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expiresAt := time.Now().Add(c.ttl)
+	if element, ok := c.entries[key]; ok {
+		e := element.Value.(*entry[K, V])
+		e.value, e.expiresAt = value, expiresAt
+		c.order.MoveToFront(element)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&entry[K, V]{key: key, value: value, expiresAt: expiresAt})
+	for c.order.Len() > c.size {
+		c.remove(c.order.Back())
+		c.evictions.Inc()
+	}
+}
+
+func (c *LRU[K, V]) Delete(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.entries[key]; ok {
+		c.remove(element)
+	}
+}
+
+// Purge drops every entry.
+func (c *LRU[K, V]) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	clear(c.entries)
+	c.order.Init()
+}
+
+func (c *LRU[K, V]) remove(element *list.Element) {
+	c.order.Remove(element)
+	delete(c.entries, element.Value.(*entry[K, V]).key)
+}
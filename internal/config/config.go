@@ -0,0 +1,273 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/yaml.v3"
+)
+
+type Config struct {
+	Server   Server   `yaml:"server" toml:"server"`
+	Postgres Postgres `yaml:"postgres" toml:"postgres"`
+	API      API      `yaml:"api" toml:"api"`
+	Cache    Cache    `yaml:"cache" toml:"cache"`
+	Log      Log      `yaml:"log" toml:"log"`
+}
+
+type Server struct {
+	Addr            string        `yaml:"addr" toml:"addr"`
+	ReadTimeout     time.Duration `yaml:"read_timeout" toml:"read_timeout"`
+	WriteTimeout    time.Duration `yaml:"write_timeout" toml:"write_timeout"`
+	IdleTimeout     time.Duration `yaml:"idle_timeout" toml:"idle_timeout"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" toml:"shutdown_timeout"`
+	// CompressMinSize is the smallest response body compressed with gzip or
+	// brotli. Zero disables compression.
+	CompressMinSize int `yaml:"compress_min_size" toml:"compress_min_size"`
+}
+
+type Postgres struct {
+	DSN string `yaml:"dsn" toml:"dsn"`
+	// ReplicaDSNs lists read replicas of DSN. Reads of GET requests are
+	// spread across them; everything else stays on the primary.
+	ReplicaDSNs []string `yaml:"replica_dsns" toml:"replica_dsns"`
+	MaxConns    int32    `yaml:"max_conns" toml:"max_conns"`
+	// CopyThreshold is the number of posts in one request above which they
+	// are written with COPY. Zero always inserts them one by one.
+	CopyThreshold int `yaml:"copy_threshold" toml:"copy_threshold"`
+	// AutoMigrate applies pending embedded migrations before serving.
+	AutoMigrate bool `yaml:"auto_migrate" toml:"auto_migrate"`
+}
+
+type API struct {
+	DefaultLimit int `yaml:"default_limit" toml:"default_limit"`
+}
+
+// Cache bounds the in-process cache of forum, thread and user rows. Each
+// kind holds up to Size entries for TTL; a zero Size disables caching.
+type Cache struct {
+	Size int           `yaml:"size" toml:"size"`
+	TTL  time.Duration `yaml:"ttl" toml:"ttl"`
+}
+
+type Log struct {
+	Level   string `yaml:"level" toml:"level"`
+	Queries bool   `yaml:"queries" toml:"queries"`
+}
+
+func Default() Config {
+	return Config{
+		Server: Server{
+			Addr:            "0.0.0.0:8000",
+			ReadTimeout:     10 * time.Second,
+			WriteTimeout:    10 * time.Second,
+			IdleTimeout:     time.Minute,
+			ShutdownTimeout: 30 * time.Second,
+			CompressMinSize: 1024,
+		},
+		Postgres: Postgres{
+			CopyThreshold: 100,
+		},
+		API: API{
+			DefaultLimit: 1000,
+		},
+		Cache: Cache{
+			Size: 10000,
+			TTL:  time.Minute,
+		},
+		Log: Log{
+			Level: "info",
+		},
+	}
+}
+
+// Load builds the configuration from defaults, then the file given by
+// -config or CONFIG_FILE, then environment variables, then the remaining
+// command line flags, and validates the result.
+func Load(args []string) (*Config, error) {
+	cfg := Default()
+
+	flags := flag.NewFlagSet("server", flag.ContinueOnError)
+	path := flags.String("config", os.Getenv("CONFIG_FILE"), "path to a YAML or TOML config file")
+	addr := flags.String("addr", "", "listen address, overrides server.addr")
+	dsn := flags.String("dsn", "", "postgres DSN, overrides postgres.dsn")
+	logLevel := flags.String("log-level", "", "log level, overrides log.level")
+	err := flags.Parse(args)
+	if err != nil {
+		return nil, err
+	}
+
+	if *path != "" {
+		err = cfg.loadFile(*path)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = cfg.loadEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	if *addr != "" {
+		cfg.Server.Addr = *addr
+	}
+	if *dsn != "" {
+		cfg.Postgres.DSN = *dsn
+	}
+	if *logLevel != "" {
+		cfg.Log.Level = *logLevel
+	}
+
+	err = cfg.Validate()
+	if err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+
+	return &cfg, nil
+}
+
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read config file: %w", err)
+	}
+
+	switch filepath.Ext(path) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, c)
+	case ".toml":
+		err = toml.Unmarshal(data, c)
+	default:
+		return fmt.Errorf("config file %s: unsupported extension, want .yaml, .yml or .toml", path)
+	}
+	if err != nil {
+		return fmt.Errorf("parse config file %s: %w", path, err)
+	}
+
+	return nil
+}
+
+func (c *Config) loadEnv() error {
+	var errs []error
+
+	setString := func(name string, dst *string) {
+		if value, ok := os.LookupEnv(name); ok && value != "" {
+			*dst = value
+		}
+	}
+	setDuration := func(name string, dst *time.Duration) {
+		if value, ok := os.LookupEnv(name); ok && value != "" {
+			d, err := time.ParseDuration(value)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", name, err))
+				return
+			}
+			*dst = d
+		}
+	}
+	setBool := func(name string, dst *bool) {
+		if value, ok := os.LookupEnv(name); ok && value != "" {
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", name, err))
+				return
+			}
+			*dst = b
+		}
+	}
+	setList := func(name string, dst *[]string) {
+		if value, ok := os.LookupEnv(name); ok && value != "" {
+			*dst = strings.Split(value, ",")
+		}
+	}
+	setInt := func(name string, bitSize int, dst func(int64)) {
+		if value, ok := os.LookupEnv(name); ok && value != "" {
+			n, err := strconv.ParseInt(value, 10, bitSize)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", name, err))
+				return
+			}
+			dst(n)
+		}
+	}
+
+	setString("SERVER_ADDR", &c.Server.Addr)
+	setDuration("SERVER_READ_TIMEOUT", &c.Server.ReadTimeout)
+	setDuration("SERVER_WRITE_TIMEOUT", &c.Server.WriteTimeout)
+	setDuration("SERVER_IDLE_TIMEOUT", &c.Server.IdleTimeout)
+	setDuration("SERVER_SHUTDOWN_TIMEOUT", &c.Server.ShutdownTimeout)
+	setInt("SERVER_COMPRESS_MIN_SIZE", 0, func(n int64) { c.Server.CompressMinSize = int(n) })
+	setString("POSTGRES_DSN", &c.Postgres.DSN)
+	setList("POSTGRES_REPLICA_DSNS", &c.Postgres.ReplicaDSNs)
+	setInt("POSTGRES_MAX_CONNS", 32, func(n int64) { c.Postgres.MaxConns = int32(n) })
+	setInt("POSTGRES_COPY_THRESHOLD", 0, func(n int64) { c.Postgres.CopyThreshold = int(n) })
+	setBool("POSTGRES_AUTO_MIGRATE", &c.Postgres.AutoMigrate)
+	setInt("API_DEFAULT_LIMIT", 0, func(n int64) { c.API.DefaultLimit = int(n) })
+	setInt("CACHE_SIZE", 0, func(n int64) { c.Cache.Size = int(n) })
+	setDuration("CACHE_TTL", &c.Cache.TTL)
+	setString("LOG_LEVEL", &c.Log.Level)
+	setBool("LOG_QUERIES", &c.Log.Queries)
+
+	return errors.Join(errs...)
+}
+
+// Validate reports every invalid field at once.
+func (c *Config) Validate() error {
+	var errs []error
+
+	if c.Server.Addr == "" {
+		errs = append(errs, errors.New("server.addr: must be set"))
+	}
+	if c.Server.ReadTimeout < 0 {
+		errs = append(errs, errors.New("server.read_timeout: must not be negative"))
+	}
+	if c.Server.WriteTimeout < 0 {
+		errs = append(errs, errors.New("server.write_timeout: must not be negative"))
+	}
+	if c.Server.IdleTimeout < 0 {
+		errs = append(errs, errors.New("server.idle_timeout: must not be negative"))
+	}
+	if c.Server.ShutdownTimeout <= 0 {
+		errs = append(errs, errors.New("server.shutdown_timeout: must be positive"))
+	}
+	if c.Server.CompressMinSize < 0 {
+		errs = append(errs, errors.New("server.compress_min_size: must not be negative"))
+	}
+	if c.Postgres.DSN == "" {
+		errs = append(errs, errors.New("postgres.dsn: must be set"))
+	}
+	for i, dsn := range c.Postgres.ReplicaDSNs {
+		if strings.TrimSpace(dsn) == "" {
+			errs = append(errs, fmt.Errorf("postgres.replica_dsns[%d]: must not be empty", i))
+		}
+	}
+	if c.Postgres.MaxConns < 0 {
+		errs = append(errs, errors.New("postgres.max_conns: must not be negative"))
+	}
+	if c.Postgres.CopyThreshold < 0 {
+		errs = append(errs, errors.New("postgres.copy_threshold: must not be negative"))
+	}
+	if c.API.DefaultLimit <= 0 {
+		errs = append(errs, errors.New("api.default_limit: must be positive"))
+	}
+	if c.Cache.Size < 0 {
+		errs = append(errs, errors.New("cache.size: must not be negative"))
+	}
+	if c.Cache.Size > 0 && c.Cache.TTL <= 0 {
+		errs = append(errs, errors.New("cache.ttl: must be positive"))
+	}
+	if _, err := zapcore.ParseLevel(c.Log.Level); err != nil {
+		errs = append(errs, fmt.Errorf("log.level: %w", err))
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,46 @@
+package cursor
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"github.com/goccy/go-json"
+)
+
+// Header carries the cursor of the next page. It is omitted once the last
+// page has been served.
+const Header = "X-Next-Cursor"
+
+// Param is the query parameter a client passes the cursor back in.
+const Param = "cursor"
+
+var ErrInvalid = errors.New("invalid cursor")
+
+// Encode turns a keyset position into an opaque URL safe token. Clients must
+// not rely on its contents, so the layout can change between releases.
+func Encode(position any) string {
+	data, err := json.Marshal(position)
+	if err != nil {
+		// positions are plain structs of strings, numbers and times
+		panic(fmt.Sprintf("encode cursor: %v", err))
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// Decode reads a token produced by Encode into position.
+func Decode(token string, position any) error {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	err = decoder.Decode(position)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	return nil
+}
@@ -69,9 +69,9 @@ func (b *CreateForumUserBatchResults) Close() error {
 }
 
 const createPosts = `-- name: CreatePosts :batchone
-INSERT INTO posts (message, parent_id, user_nn, thread_id, path)
-VALUES ($1, $2, $3, $4, $5)
-RETURNING id, created, isedited, message, parent_id, user_nn, thread_id, path
+INSERT INTO posts (id, message, parent_id, user_nn, thread_id, path, root_id, sort_key)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING id, created, isedited, message, parent_id, user_nn, thread_id, path, root_id, sort_key, votes
 `
 
 type CreatePostsBatchResults struct {
@@ -81,22 +81,28 @@ type CreatePostsBatchResults struct {
 }
 
 type CreatePostsParams struct {
+	ID       int32
 	Message  string
 	ParentID pgtype.Int4
 	UserNn   string
 	ThreadID int32
 	Path     []int32
+	RootID   int32
+	SortKey  []int32
 }
 
 func (q *Queries) CreatePosts(ctx context.Context, arg []CreatePostsParams) *CreatePostsBatchResults {
 	batch := &pgx.Batch{}
 	for _, a := range arg {
 		vals := []interface{}{
+			a.ID,
 			a.Message,
 			a.ParentID,
 			a.UserNn,
 			a.ThreadID,
 			a.Path,
+			a.RootID,
+			a.SortKey,
 		}
 		batch.Queue(createPosts, vals...)
 	}
@@ -124,6 +130,9 @@ func (b *CreatePostsBatchResults) QueryRow(f func(int, Post, error)) {
 			&i.UserNn,
 			&i.ThreadID,
 			&i.Path,
+			&i.RootID,
+			&i.SortKey,
+			&i.Votes,
 		)
 		if f != nil {
 			f(t, i, err)
@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- the cursor compares (created, id) as a row, which is never true for a NULL
+-- created, so such threads would be skipped by every page after the first
+UPDATE threads
+SET created = CURRENT_TIMESTAMP
+WHERE created IS NULL;
+
+-- forum thread listings page on (created, id)
+CREATE INDEX threads__forum_created_id
+    ON threads (forum_slug, created, id);
+
+DROP INDEX threads__forum_created;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+CREATE INDEX threads__forum_created
+    ON threads (forum_slug, created);
+
+DROP INDEX threads__forum_created_id;
+
+-- +goose StatementEnd
@@ -0,0 +1,42 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- root_id is the id of the root post of the tree a post belongs to, sort_key
+-- its path followed by its own id. Both are set on insert, so tree and
+-- parent_tree pages are range scans instead of sorting the whole thread.
+ALTER TABLE posts
+    ADD COLUMN root_id  INTEGER,
+    ADD COLUMN sort_key INTEGER ARRAY;
+
+UPDATE posts
+SET root_id  = COALESCE(path[1], id),
+    sort_key = path || id;
+
+ALTER TABLE posts
+    ALTER COLUMN root_id SET NOT NULL,
+    ALTER COLUMN sort_key SET NOT NULL;
+
+CREATE INDEX posts__thread_id_sort_key
+    ON posts (thread_id, sort_key);
+
+CREATE INDEX posts__root_id_sort_key
+    ON posts (root_id, sort_key);
+
+CREATE INDEX posts__thread_id_roots
+    ON posts (thread_id, id)
+    WHERE parent_id IS NULL;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX posts__thread_id_roots;
+DROP INDEX posts__root_id_sort_key;
+DROP INDEX posts__thread_id_sort_key;
+
+ALTER TABLE posts
+    DROP COLUMN sort_key,
+    DROP COLUMN root_id;
+
+-- +goose StatementEnd
@@ -0,0 +1,70 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- A vote is stored as -1 or 1, retracting it deletes the row. Rows written
+-- before may hold other values, so the constraint is not validated for them.
+ALTER TABLE votes
+    ADD CONSTRAINT votes_voice_check CHECK (voice IN (-1, 1)) NOT VALID;
+
+-- The thread's tally changes by the new voice minus the old one, whatever
+-- the values are.
+CREATE OR REPLACE FUNCTION voteupdate()
+    RETURNS TRIGGER AS
+$BODY$
+BEGIN
+    IF new.voice IS DISTINCT FROM old.voice
+    THEN
+        UPDATE threads
+        SET votes = votes + COALESCE(new.voice, 0) - COALESCE(old.voice, 0)
+        WHERE id = new.thread_id;
+    END IF;
+    RETURN new;
+END;
+$BODY$
+    LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION votedelete()
+    RETURNS TRIGGER AS
+$BODY$
+BEGIN
+    UPDATE threads SET votes = votes - COALESCE(old.voice, 0) WHERE id = old.thread_id;
+    RETURN old;
+END;
+$BODY$
+    LANGUAGE plpgsql;
+
+CREATE TRIGGER votedelete
+    AFTER DELETE
+    ON votes
+    FOR EACH ROW
+EXECUTE PROCEDURE votedelete();
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TRIGGER votedelete ON votes;
+DROP FUNCTION votedelete;
+
+CREATE OR REPLACE FUNCTION voteupdate()
+    RETURNS TRIGGER AS
+$BODY$
+BEGIN
+    IF old.voice = -1 AND new.voice = 1
+    THEN
+        UPDATE threads SET votes = votes + 2 WHERE id = new.thread_id;
+    END IF;
+    IF old.voice = 1 AND new.voice = -1
+    THEN
+        UPDATE threads SET votes = votes - 2 WHERE id = new.thread_id;
+    END IF;
+    RETURN new;
+END;
+$BODY$
+    LANGUAGE plpgsql;
+
+ALTER TABLE votes
+    DROP CONSTRAINT votes_voice_check;
+
+-- +goose StatementEnd
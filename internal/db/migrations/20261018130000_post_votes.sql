@@ -0,0 +1,84 @@
+-- +goose Up
+-- +goose StatementBegin
+
+ALTER TABLE posts
+    ADD COLUMN votes INTEGER NOT NULL DEFAULT 0; -- Denormalization
+
+-- Votes on posts follow thread votes: -1 or 1, retracting deletes the row,
+-- and the post's score moves by the new voice minus the old one.
+CREATE TABLE post_votes
+(
+    post_id INTEGER REFERENCES posts (id)        NOT NULL,
+    user_nn citext REFERENCES users (nickname) NOT NULL,
+    voice   INTEGER                            NOT NULL CHECK (voice IN (-1, 1)),
+    CONSTRAINT post_votes_post_user_unique UNIQUE (post_id, user_nn)
+);
+
+CREATE OR REPLACE FUNCTION postvoteinsert()
+    RETURNS TRIGGER AS
+$BODY$
+BEGIN
+    UPDATE posts SET votes = votes + new.voice WHERE id = new.post_id;
+    RETURN new;
+END;
+$BODY$
+    LANGUAGE plpgsql;
+
+CREATE TRIGGER postvoteinsert
+    AFTER INSERT
+    ON post_votes
+    FOR EACH ROW
+EXECUTE PROCEDURE postvoteinsert();
+
+CREATE OR REPLACE FUNCTION postvoteupdate()
+    RETURNS TRIGGER AS
+$BODY$
+BEGIN
+    IF new.voice <> old.voice
+    THEN
+        UPDATE posts SET votes = votes + new.voice - old.voice WHERE id = new.post_id;
+    END IF;
+    RETURN new;
+END;
+$BODY$
+    LANGUAGE plpgsql;
+
+CREATE TRIGGER postvoteupdate
+    AFTER UPDATE
+    ON post_votes
+    FOR EACH ROW
+EXECUTE PROCEDURE postvoteupdate();
+
+CREATE OR REPLACE FUNCTION postvotedelete()
+    RETURNS TRIGGER AS
+$BODY$
+BEGIN
+    UPDATE posts SET votes = votes - old.voice WHERE id = old.post_id;
+    RETURN old;
+END;
+$BODY$
+    LANGUAGE plpgsql;
+
+CREATE TRIGGER postvotedelete
+    AFTER DELETE
+    ON post_votes
+    FOR EACH ROW
+EXECUTE PROCEDURE postvotedelete();
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TRIGGER postvotedelete ON post_votes;
+DROP FUNCTION postvotedelete;
+DROP TRIGGER postvoteupdate ON post_votes;
+DROP FUNCTION postvoteupdate;
+DROP TRIGGER postvoteinsert ON post_votes;
+DROP FUNCTION postvoteinsert;
+DROP TABLE post_votes;
+
+ALTER TABLE posts
+    DROP COLUMN votes;
+
+-- +goose StatementEnd
@@ -0,0 +1,75 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- A revision keeps what a post or thread said before an edit. The triggers
+-- only fire when the text changes, not on counter updates.
+CREATE TABLE post_revisions
+(
+    id      SERIAL PRIMARY KEY,
+    post_id INTEGER REFERENCES posts (id) NOT NULL,
+    message TEXT                          NOT NULL,
+    edited  TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+CREATE INDEX post_revisions__post_id
+    ON post_revisions (post_id, id);
+
+CREATE TABLE thread_revisions
+(
+    id        SERIAL PRIMARY KEY,
+    thread_id INTEGER REFERENCES threads (id) NOT NULL,
+    title     TEXT                            NOT NULL,
+    message   TEXT,
+    edited    TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+CREATE INDEX thread_revisions__thread_id
+    ON thread_revisions (thread_id, id);
+
+CREATE OR REPLACE FUNCTION postrevision()
+    RETURNS TRIGGER AS
+$BODY$
+BEGIN
+    INSERT INTO post_revisions (post_id, message) VALUES (old.id, old.message);
+    RETURN new;
+END;
+$BODY$
+    LANGUAGE plpgsql;
+
+CREATE TRIGGER postrevision
+    AFTER UPDATE OF message
+    ON posts
+    FOR EACH ROW
+    WHEN (old.message IS DISTINCT FROM new.message)
+EXECUTE PROCEDURE postrevision();
+
+CREATE OR REPLACE FUNCTION threadrevision()
+    RETURNS TRIGGER AS
+$BODY$
+BEGIN
+    INSERT INTO thread_revisions (thread_id, title, message) VALUES (old.id, old.title, old.message);
+    RETURN new;
+END;
+$BODY$
+    LANGUAGE plpgsql;
+
+CREATE TRIGGER threadrevision
+    AFTER UPDATE OF title, message
+    ON threads
+    FOR EACH ROW
+    WHEN (old.title IS DISTINCT FROM new.title OR old.message IS DISTINCT FROM new.message)
+EXECUTE PROCEDURE threadrevision();
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TRIGGER threadrevision ON threads;
+DROP FUNCTION threadrevision;
+DROP TRIGGER postrevision ON posts;
+DROP FUNCTION postrevision;
+DROP TABLE thread_revisions;
+DROP TABLE post_revisions;
+
+-- +goose StatementEnd
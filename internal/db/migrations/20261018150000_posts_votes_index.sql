@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- flat_top pages of a thread's posts read this index in order, backwards
+-- when descending, so a page costs its limit however long the thread is
+CREATE INDEX posts__thread_votes_id
+    ON posts (thread_id, votes DESC, id);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX posts__thread_votes_id;
+
+-- +goose StatementEnd
@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- flat post listings page on (created, id)
+CREATE INDEX posts__thread_created_id
+    ON posts (thread_id, created, id);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX posts__thread_created_id;
+
+-- +goose StatementEnd
@@ -0,0 +1,25 @@
+// Package migrations embeds the goose SQL migrations, so the server binary
+// can bring a fresh database up to date without the goose CLI.
+package migrations
+
+import (
+	"database/sql"
+	"embed"
+
+	"github.com/pressly/goose/v3"
+	"github.com/pressly/goose/v3/lock"
+)
+
+//go:embed *.sql
+var FS embed.FS
+
+// NewProvider returns a goose provider applying the embedded migrations to
+// db. It holds a Postgres advisory lock while migrating, so servers starting
+// together with auto-migrate apply each migration once.
+func NewProvider(db *sql.DB) (*goose.Provider, error) {
+	locker, err := lock.NewPostgresSessionLocker()
+	if err != nil {
+		return nil, err
+	}
+	return goose.NewProvider(goose.DialectPostgres, db, FS, goose.WithSessionLocker(locker))
+}
@@ -30,6 +30,22 @@ type Post struct {
 	UserNn   string
 	ThreadID int32
 	Path     []int32
+	RootID   int32
+	SortKey  []int32
+	Votes    int32
+}
+
+type PostRevision struct {
+	ID      int32
+	PostID  int32
+	Message string
+	Edited  pgtype.Timestamptz
+}
+
+type PostVote struct {
+	PostID int32
+	UserNn string
+	Voice  int32
 }
 
 type Thread struct {
@@ -43,6 +59,14 @@ type Thread struct {
 	ForumSlug string
 }
 
+type ThreadRevision struct {
+	ID       int32
+	ThreadID int32
+	Title    string
+	Message  pgtype.Text
+	Edited   pgtype.Timestamptz
+}
+
 type User struct {
 	ID       int32
 	Nickname string
@@ -7,10 +7,89 @@ package db
 
 import (
 	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
+type CopyPostsParams struct {
+	ID       int32
+	Message  string
+	ParentID pgtype.Int4
+	UserNn   string
+	ThreadID int32
+	Path     []int32
+	RootID   int32
+	SortKey  []int32
+}
+
+const countPostRevisions = `-- name: CountPostRevisions :one
+SELECT COUNT(*)::INT AS count
+FROM post_revisions
+WHERE post_id = $1
+`
+
+func (q *Queries) CountPostRevisions(ctx context.Context, postID int32) (int32, error) {
+	row := q.db.QueryRow(ctx, countPostRevisions, postID)
+	var count int32
+	err := row.Scan(&count)
+	return count, err
+}
+
+const getPostFull = `-- name: GetPostFull :one
+SELECT p.id, p.created, p.isedited, p.message, p.parent_id, p.user_nn, p.thread_id, p.path, p.root_id, p.sort_key, p.votes, u.id, u.nickname, u.fullname, u.email, u.about, t.id, t.slug, t.created, t.title, t.message, t.votes, t.user_nn, t.forum_slug, f.slug, f.title, f.user_nn, f.posts, f.threads
+FROM posts p
+    JOIN users u ON p.user_nn = u.nickname
+    JOIN threads t ON p.thread_id = t.id
+    JOIN forums f ON t.forum_slug = f.slug
+WHERE p.id = $1
+`
+
+type GetPostFullRow struct {
+	Post   Post
+	User   User
+	Thread Thread
+	Forum  Forum
+}
+
+func (q *Queries) GetPostFull(ctx context.Context, id int32) (GetPostFullRow, error) {
+	row := q.db.QueryRow(ctx, getPostFull, id)
+	var i GetPostFullRow
+	err := row.Scan(
+		&i.Post.ID,
+		&i.Post.Created,
+		&i.Post.Isedited,
+		&i.Post.Message,
+		&i.Post.ParentID,
+		&i.Post.UserNn,
+		&i.Post.ThreadID,
+		&i.Post.Path,
+		&i.Post.RootID,
+		&i.Post.SortKey,
+		&i.Post.Votes,
+		&i.User.ID,
+		&i.User.Nickname,
+		&i.User.Fullname,
+		&i.User.Email,
+		&i.User.About,
+		&i.Thread.ID,
+		&i.Thread.Slug,
+		&i.Thread.Created,
+		&i.Thread.Title,
+		&i.Thread.Message,
+		&i.Thread.Votes,
+		&i.Thread.UserNn,
+		&i.Thread.ForumSlug,
+		&i.Forum.Slug,
+		&i.Forum.Title,
+		&i.Forum.UserNn,
+		&i.Forum.Posts,
+		&i.Forum.Threads,
+	)
+	return i, err
+}
+
 const listByID = `-- name: ListByID :many
-SELECT id, created, isedited, message, parent_id, user_nn, thread_id, path
+SELECT id, created, isedited, message, parent_id, user_nn, thread_id, path, root_id, sort_key, votes
 FROM posts
 WHERE id = ANY($1::int[])
 `
@@ -33,6 +112,695 @@ func (q *Queries) ListByID(ctx context.Context, dollar_1 []int32) ([]Post, error
 			&i.UserNn,
 			&i.ThreadID,
 			&i.Path,
+			&i.RootID,
+			&i.SortKey,
+			&i.Votes,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPostRevisions = `-- name: ListPostRevisions :many
+SELECT id, post_id, message, edited
+FROM post_revisions
+WHERE post_id = $1
+ORDER BY id
+`
+
+func (q *Queries) ListPostRevisions(ctx context.Context, postID int32) ([]PostRevision, error) {
+	rows, err := q.db.Query(ctx, listPostRevisions, postID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PostRevision
+	for rows.Next() {
+		var i PostRevision
+		if err := rows.Scan(
+			&i.ID,
+			&i.PostID,
+			&i.Message,
+			&i.Edited,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPostsFlat = `-- name: ListPostsFlat :many
+SELECT id, created, isedited, message, parent_id, user_nn, thread_id, path, root_id, sort_key, votes
+FROM posts
+WHERE thread_id = $1
+  AND ($2::INT IS NULL OR id > $2::INT)
+  AND ($3::TIMESTAMPTZ IS NULL
+    OR (created, id) > ($3::TIMESTAMPTZ, $4::INT))
+ORDER BY created, id
+LIMIT $5
+`
+
+type ListPostsFlatParams struct {
+	ThreadID     int32
+	Since        pgtype.Int4
+	AfterCreated pgtype.Timestamptz
+	AfterID      pgtype.Int4
+	Limit        int32
+}
+
+// A cursor continues after (after_created, after_id) in the order of the
+// page. Ids are taken after the transaction start that sets created, so
+// they don't follow that order and since, the id of a post, is only kept
+// as the filter it always was.
+func (q *Queries) ListPostsFlat(ctx context.Context, arg ListPostsFlatParams) ([]Post, error) {
+	rows, err := q.db.Query(ctx, listPostsFlat,
+		arg.ThreadID,
+		arg.Since,
+		arg.AfterCreated,
+		arg.AfterID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Post
+	for rows.Next() {
+		var i Post
+		if err := rows.Scan(
+			&i.ID,
+			&i.Created,
+			&i.Isedited,
+			&i.Message,
+			&i.ParentID,
+			&i.UserNn,
+			&i.ThreadID,
+			&i.Path,
+			&i.RootID,
+			&i.SortKey,
+			&i.Votes,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPostsFlatDesc = `-- name: ListPostsFlatDesc :many
+SELECT id, created, isedited, message, parent_id, user_nn, thread_id, path, root_id, sort_key, votes
+FROM posts
+WHERE thread_id = $1
+  AND ($2::INT IS NULL OR id < $2::INT)
+  AND ($3::TIMESTAMPTZ IS NULL
+    OR (created, id) < ($3::TIMESTAMPTZ, $4::INT))
+ORDER BY created DESC, id DESC
+LIMIT $5
+`
+
+type ListPostsFlatDescParams struct {
+	ThreadID     int32
+	Since        pgtype.Int4
+	AfterCreated pgtype.Timestamptz
+	AfterID      pgtype.Int4
+	Limit        int32
+}
+
+func (q *Queries) ListPostsFlatDesc(ctx context.Context, arg ListPostsFlatDescParams) ([]Post, error) {
+	rows, err := q.db.Query(ctx, listPostsFlatDesc,
+		arg.ThreadID,
+		arg.Since,
+		arg.AfterCreated,
+		arg.AfterID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Post
+	for rows.Next() {
+		var i Post
+		if err := rows.Scan(
+			&i.ID,
+			&i.Created,
+			&i.Isedited,
+			&i.Message,
+			&i.ParentID,
+			&i.UserNn,
+			&i.ThreadID,
+			&i.Path,
+			&i.RootID,
+			&i.SortKey,
+			&i.Votes,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPostsFlatTop = `-- name: ListPostsFlatTop :many
+WITH anchor AS (
+    SELECT COALESCE($1::INT[], (SELECT ARRAY [-s.votes, s.id] FROM posts s WHERE s.id = $2::INT)) AS rank_key
+)
+SELECT p.id, p.created, p.isedited, p.message, p.parent_id, p.user_nn, p.thread_id, p.path, p.root_id, p.sort_key, p.votes
+FROM posts p, anchor a
+WHERE p.thread_id = $3
+  AND ($2::INT IS NULL AND $1::INT[] IS NULL
+    OR p.votes < -a.rank_key[1]
+    OR p.votes = -a.rank_key[1] AND p.id > a.rank_key[2])
+ORDER BY p.votes DESC, p.id
+LIMIT $4
+`
+
+type ListPostsFlatTopParams struct {
+	AfterRank []int32
+	Since     pgtype.Int4
+	ThreadID  int32
+	Limit     int32
+}
+
+type ListPostsFlatTopRow struct {
+	Post Post
+}
+
+// Orders the thread's posts by votes, highest first, then by id, without
+// regard to the tree. The page starts after a rank key, [-votes, id], taken
+// from a cursor or from a since post that is looked up. Reads
+// posts__thread_votes_id.
+func (q *Queries) ListPostsFlatTop(ctx context.Context, arg ListPostsFlatTopParams) ([]ListPostsFlatTopRow, error) {
+	rows, err := q.db.Query(ctx, listPostsFlatTop,
+		arg.AfterRank,
+		arg.Since,
+		arg.ThreadID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListPostsFlatTopRow
+	for rows.Next() {
+		var i ListPostsFlatTopRow
+		if err := rows.Scan(
+			&i.Post.ID,
+			&i.Post.Created,
+			&i.Post.Isedited,
+			&i.Post.Message,
+			&i.Post.ParentID,
+			&i.Post.UserNn,
+			&i.Post.ThreadID,
+			&i.Post.Path,
+			&i.Post.RootID,
+			&i.Post.SortKey,
+			&i.Post.Votes,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPostsFlatTopDesc = `-- name: ListPostsFlatTopDesc :many
+WITH anchor AS (
+    SELECT COALESCE($1::INT[], (SELECT ARRAY [-s.votes, s.id] FROM posts s WHERE s.id = $2::INT)) AS rank_key
+)
+SELECT p.id, p.created, p.isedited, p.message, p.parent_id, p.user_nn, p.thread_id, p.path, p.root_id, p.sort_key, p.votes
+FROM posts p, anchor a
+WHERE p.thread_id = $3
+  AND ($2::INT IS NULL AND $1::INT[] IS NULL
+    OR p.votes > -a.rank_key[1]
+    OR p.votes = -a.rank_key[1] AND p.id < a.rank_key[2])
+ORDER BY p.votes, p.id DESC
+LIMIT $4
+`
+
+type ListPostsFlatTopDescParams struct {
+	AfterRank []int32
+	Since     pgtype.Int4
+	ThreadID  int32
+	Limit     int32
+}
+
+type ListPostsFlatTopDescRow struct {
+	Post Post
+}
+
+func (q *Queries) ListPostsFlatTopDesc(ctx context.Context, arg ListPostsFlatTopDescParams) ([]ListPostsFlatTopDescRow, error) {
+	rows, err := q.db.Query(ctx, listPostsFlatTopDesc,
+		arg.AfterRank,
+		arg.Since,
+		arg.ThreadID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListPostsFlatTopDescRow
+	for rows.Next() {
+		var i ListPostsFlatTopDescRow
+		if err := rows.Scan(
+			&i.Post.ID,
+			&i.Post.Created,
+			&i.Post.Isedited,
+			&i.Post.Message,
+			&i.Post.ParentID,
+			&i.Post.UserNn,
+			&i.Post.ThreadID,
+			&i.Post.Path,
+			&i.Post.RootID,
+			&i.Post.SortKey,
+			&i.Post.Votes,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPostsParentTree = `-- name: ListPostsParentTree :many
+WITH anchor AS (
+    SELECT COALESCE($1::INT[], (SELECT s.sort_key FROM posts s WHERE s.id = $2::INT)) AS sort_key
+),
+roots AS (
+    SELECT r.id
+    FROM posts r, anchor a
+    WHERE r.thread_id = $3
+      AND r.parent_id IS NULL
+      AND ($2::INT IS NULL AND $1::INT[] IS NULL OR r.id > a.sort_key[1])
+    ORDER BY r.id
+    LIMIT $4
+)
+SELECT p.id, p.created, p.isedited, p.message, p.parent_id, p.user_nn, p.thread_id, p.path, p.root_id, p.sort_key, p.votes
+FROM posts p, anchor a
+WHERE p.thread_id = $3
+  AND (p.root_id IN (SELECT id FROM roots)
+    OR p.root_id = a.sort_key[1] AND p.sort_key > a.sort_key)
+ORDER BY p.root_id, p.sort_key
+`
+
+type ListPostsParentTreeParams struct {
+	AfterPath []int32
+	Since     pgtype.Int4
+	ThreadID  int32
+	Limit     int32
+}
+
+type ListPostsParentTreeRow struct {
+	Post Post
+}
+
+// limit counts root posts. After an anchor, given as in ListPostsTree, the
+// page finishes the anchor's tree before taking limit more trees.
+func (q *Queries) ListPostsParentTree(ctx context.Context, arg ListPostsParentTreeParams) ([]ListPostsParentTreeRow, error) {
+	rows, err := q.db.Query(ctx, listPostsParentTree,
+		arg.AfterPath,
+		arg.Since,
+		arg.ThreadID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListPostsParentTreeRow
+	for rows.Next() {
+		var i ListPostsParentTreeRow
+		if err := rows.Scan(
+			&i.Post.ID,
+			&i.Post.Created,
+			&i.Post.Isedited,
+			&i.Post.Message,
+			&i.Post.ParentID,
+			&i.Post.UserNn,
+			&i.Post.ThreadID,
+			&i.Post.Path,
+			&i.Post.RootID,
+			&i.Post.SortKey,
+			&i.Post.Votes,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPostsParentTreeDesc = `-- name: ListPostsParentTreeDesc :many
+WITH anchor AS (
+    SELECT COALESCE($1::INT[], (SELECT s.sort_key FROM posts s WHERE s.id = $2::INT)) AS sort_key
+),
+roots AS (
+    SELECT r.id
+    FROM posts r, anchor a
+    WHERE r.thread_id = $3
+      AND r.parent_id IS NULL
+      AND ($2::INT IS NULL AND $1::INT[] IS NULL OR r.id < a.sort_key[1])
+    ORDER BY r.id DESC
+    LIMIT $4
+)
+SELECT p.id, p.created, p.isedited, p.message, p.parent_id, p.user_nn, p.thread_id, p.path, p.root_id, p.sort_key, p.votes
+FROM posts p, anchor a
+WHERE p.thread_id = $3
+  AND (p.root_id IN (SELECT id FROM roots)
+    OR p.root_id = a.sort_key[1] AND p.sort_key > a.sort_key)
+ORDER BY p.root_id DESC, p.sort_key
+`
+
+type ListPostsParentTreeDescParams struct {
+	AfterPath []int32
+	Since     pgtype.Int4
+	ThreadID  int32
+	Limit     int32
+}
+
+type ListPostsParentTreeDescRow struct {
+	Post Post
+}
+
+func (q *Queries) ListPostsParentTreeDesc(ctx context.Context, arg ListPostsParentTreeDescParams) ([]ListPostsParentTreeDescRow, error) {
+	rows, err := q.db.Query(ctx, listPostsParentTreeDesc,
+		arg.AfterPath,
+		arg.Since,
+		arg.ThreadID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListPostsParentTreeDescRow
+	for rows.Next() {
+		var i ListPostsParentTreeDescRow
+		if err := rows.Scan(
+			&i.Post.ID,
+			&i.Post.Created,
+			&i.Post.Isedited,
+			&i.Post.Message,
+			&i.Post.ParentID,
+			&i.Post.UserNn,
+			&i.Post.ThreadID,
+			&i.Post.Path,
+			&i.Post.RootID,
+			&i.Post.SortKey,
+			&i.Post.Votes,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPostsTop = `-- name: ListPostsTop :many
+WITH RECURSIVE ranked AS (
+    SELECT r.id, ARRAY [-r.votes, r.id] AS rank_key
+    FROM posts r
+    WHERE r.thread_id = $1
+      AND r.parent_id IS NULL
+    UNION ALL
+    SELECT c.id, parent.rank_key || ARRAY [-c.votes, c.id]
+    FROM posts c
+        JOIN ranked parent ON c.parent_id = parent.id
+    WHERE c.thread_id = $1
+),
+anchor AS (
+    SELECT COALESCE($2::INT[], (SELECT s.rank_key FROM ranked s WHERE s.id = $3::INT)) AS rank_key
+)
+SELECT p.id, p.created, p.isedited, p.message, p.parent_id, p.user_nn, p.thread_id, p.path, p.root_id, p.sort_key, p.votes, r.rank_key
+FROM ranked r
+    JOIN posts p ON p.id = r.id,
+    anchor a
+WHERE $3::INT IS NULL AND $2::INT[] IS NULL
+   OR r.rank_key > a.rank_key
+ORDER BY r.rank_key
+LIMIT $4
+`
+
+type ListPostsTopParams struct {
+	ThreadID  int32
+	AfterRank []int32
+	Since     pgtype.Int4
+	Limit     int32
+}
+
+type ListPostsTopRow struct {
+	Post    Post
+	RankKey []int32
+}
+
+// Orders the thread depth first, ranking the posts of every level by votes,
+// highest first, then by id. rank_key holds -votes and the id of every post
+// on the path to the post, so it sorts like a sort key. The page starts
+// after the rank key of a cursor or of a since post.
+//
+// Ranks depend on votes, so no index holds them: every page walks and sorts
+// the whole thread, whatever the limit. ListPostsFlatTop pages through an
+// index instead.
+func (q *Queries) ListPostsTop(ctx context.Context, arg ListPostsTopParams) ([]ListPostsTopRow, error) {
+	rows, err := q.db.Query(ctx, listPostsTop,
+		arg.ThreadID,
+		arg.AfterRank,
+		arg.Since,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListPostsTopRow
+	for rows.Next() {
+		var i ListPostsTopRow
+		if err := rows.Scan(
+			&i.Post.ID,
+			&i.Post.Created,
+			&i.Post.Isedited,
+			&i.Post.Message,
+			&i.Post.ParentID,
+			&i.Post.UserNn,
+			&i.Post.ThreadID,
+			&i.Post.Path,
+			&i.Post.RootID,
+			&i.Post.SortKey,
+			&i.Post.Votes,
+			&i.RankKey,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPostsTopDesc = `-- name: ListPostsTopDesc :many
+WITH RECURSIVE ranked AS (
+    SELECT r.id, ARRAY [-r.votes, r.id] AS rank_key
+    FROM posts r
+    WHERE r.thread_id = $1
+      AND r.parent_id IS NULL
+    UNION ALL
+    SELECT c.id, parent.rank_key || ARRAY [-c.votes, c.id]
+    FROM posts c
+        JOIN ranked parent ON c.parent_id = parent.id
+    WHERE c.thread_id = $1
+),
+anchor AS (
+    SELECT COALESCE($2::INT[], (SELECT s.rank_key FROM ranked s WHERE s.id = $3::INT)) AS rank_key
+)
+SELECT p.id, p.created, p.isedited, p.message, p.parent_id, p.user_nn, p.thread_id, p.path, p.root_id, p.sort_key, p.votes, r.rank_key
+FROM ranked r
+    JOIN posts p ON p.id = r.id,
+    anchor a
+WHERE $3::INT IS NULL AND $2::INT[] IS NULL
+   OR r.rank_key < a.rank_key
+ORDER BY r.rank_key DESC
+LIMIT $4
+`
+
+type ListPostsTopDescParams struct {
+	ThreadID  int32
+	AfterRank []int32
+	Since     pgtype.Int4
+	Limit     int32
+}
+
+type ListPostsTopDescRow struct {
+	Post    Post
+	RankKey []int32
+}
+
+func (q *Queries) ListPostsTopDesc(ctx context.Context, arg ListPostsTopDescParams) ([]ListPostsTopDescRow, error) {
+	rows, err := q.db.Query(ctx, listPostsTopDesc,
+		arg.ThreadID,
+		arg.AfterRank,
+		arg.Since,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListPostsTopDescRow
+	for rows.Next() {
+		var i ListPostsTopDescRow
+		if err := rows.Scan(
+			&i.Post.ID,
+			&i.Post.Created,
+			&i.Post.Isedited,
+			&i.Post.Message,
+			&i.Post.ParentID,
+			&i.Post.UserNn,
+			&i.Post.ThreadID,
+			&i.Post.Path,
+			&i.Post.RootID,
+			&i.Post.SortKey,
+			&i.Post.Votes,
+			&i.RankKey,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPostsTree = `-- name: ListPostsTree :many
+SELECT id, created, isedited, message, parent_id, user_nn, thread_id, path, root_id, sort_key, votes
+FROM posts
+WHERE thread_id = $1
+  AND ($2::INT IS NULL AND $3::INT[] IS NULL
+    OR sort_key > COALESCE($3::INT[], (SELECT s.sort_key FROM posts s WHERE s.id = $2::INT)))
+ORDER BY sort_key
+LIMIT $4
+`
+
+type ListPostsTreeParams struct {
+	ThreadID  int32
+	Since     pgtype.Int4
+	AfterPath []int32
+	Limit     int32
+}
+
+// The page starts after an anchor, given either as the sort key of a
+// cursor or as the id of a since post that is looked up.
+func (q *Queries) ListPostsTree(ctx context.Context, arg ListPostsTreeParams) ([]Post, error) {
+	rows, err := q.db.Query(ctx, listPostsTree,
+		arg.ThreadID,
+		arg.Since,
+		arg.AfterPath,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Post
+	for rows.Next() {
+		var i Post
+		if err := rows.Scan(
+			&i.ID,
+			&i.Created,
+			&i.Isedited,
+			&i.Message,
+			&i.ParentID,
+			&i.UserNn,
+			&i.ThreadID,
+			&i.Path,
+			&i.RootID,
+			&i.SortKey,
+			&i.Votes,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPostsTreeDesc = `-- name: ListPostsTreeDesc :many
+SELECT id, created, isedited, message, parent_id, user_nn, thread_id, path, root_id, sort_key, votes
+FROM posts
+WHERE thread_id = $1
+  AND ($2::INT IS NULL AND $3::INT[] IS NULL
+    OR sort_key < COALESCE($3::INT[], (SELECT s.sort_key FROM posts s WHERE s.id = $2::INT)))
+ORDER BY sort_key DESC
+LIMIT $4
+`
+
+type ListPostsTreeDescParams struct {
+	ThreadID  int32
+	Since     pgtype.Int4
+	AfterPath []int32
+	Limit     int32
+}
+
+func (q *Queries) ListPostsTreeDesc(ctx context.Context, arg ListPostsTreeDescParams) ([]Post, error) {
+	rows, err := q.db.Query(ctx, listPostsTreeDesc,
+		arg.ThreadID,
+		arg.Since,
+		arg.AfterPath,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Post
+	for rows.Next() {
+		var i Post
+		if err := rows.Scan(
+			&i.ID,
+			&i.Created,
+			&i.Isedited,
+			&i.Message,
+			&i.ParentID,
+			&i.UserNn,
+			&i.ThreadID,
+			&i.Path,
+			&i.RootID,
+			&i.SortKey,
+			&i.Votes,
 		); err != nil {
 			return nil, err
 		}
@@ -43,3 +811,44 @@ func (q *Queries) ListByID(ctx context.Context, dollar_1 []int32) ([]Post, error
 	}
 	return items, nil
 }
+
+const nextPostIDs = `-- name: NextPostIDs :many
+SELECT nextval(pg_get_serial_sequence('posts', 'id'))::INT AS id
+FROM generate_series(1, $1::INT)
+`
+
+func (q *Queries) NextPostIDs(ctx context.Context, count int32) ([]int32, error) {
+	rows, err := q.db.Query(ctx, nextPostIDs, count)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int32
+	for rows.Next() {
+		var id int32
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updatePostMessage = `-- name: UpdatePostMessage :exec
+UPDATE posts
+SET message = $1, isedited = TRUE
+WHERE id = $2
+`
+
+type UpdatePostMessageParams struct {
+	Message string
+	ID      int32
+}
+
+func (q *Queries) UpdatePostMessage(ctx context.Context, arg UpdatePostMessageParams) error {
+	_, err := q.db.Exec(ctx, updatePostMessage, arg.Message, arg.ID)
+	return err
+}
@@ -0,0 +1,180 @@
+-- name: LockCounterSources :exec
+-- Blocks writes to the counters and the rows they are computed from until
+-- the transaction ends. The mode conflicts with itself, so two repairs run
+-- one after the other.
+LOCK TABLE forums, threads, posts, votes, post_votes, forum_user IN SHARE ROW EXCLUSIVE MODE;
+
+-- name: ListForumDrift :many
+-- Forums whose post or thread counter or forum_user rows differ from the
+-- threads and posts of the forum.
+WITH thread_counts AS (
+    SELECT forum_slug, COUNT(*)::INT AS count
+    FROM threads
+    GROUP BY forum_slug
+),
+post_counts AS (
+    SELECT t.forum_slug, COUNT(*)::INT AS count
+    FROM posts p
+        JOIN threads t ON t.id = p.thread_id
+    GROUP BY t.forum_slug
+),
+expected_users AS (
+    SELECT t.forum_slug, u.id AS user_id
+    FROM threads t
+        JOIN users u ON u.nickname = t.user_nn
+    UNION
+    SELECT t.forum_slug, u.id
+    FROM posts p
+        JOIN threads t ON t.id = p.thread_id
+        JOIN users u ON u.nickname = p.user_nn
+),
+user_drift AS (
+    SELECT COALESCE(e.forum_slug, fu.forum_slug)                AS forum_slug,
+           COUNT(*) FILTER (WHERE fu.user_id IS NULL)::INT AS missing,
+           COUNT(*) FILTER (WHERE e.user_id IS NULL)::INT  AS extra
+    FROM expected_users e
+        FULL JOIN forum_user fu ON fu.forum_slug = e.forum_slug AND fu.user_id = e.user_id
+    WHERE e.user_id IS NULL
+       OR fu.user_id IS NULL
+    GROUP BY 1
+)
+SELECT f.slug,
+       COALESCE(f.posts, 0)::INT    AS posts,
+       COALESCE(pc.count, 0)::INT   AS actual_posts,
+       COALESCE(f.threads, 0)::INT  AS threads,
+       COALESCE(tc.count, 0)::INT   AS actual_threads,
+       COALESCE(ud.missing, 0)::INT AS missing_users,
+       COALESCE(ud.extra, 0)::INT   AS extra_users
+FROM forums f
+    LEFT JOIN thread_counts tc ON tc.forum_slug = f.slug
+    LEFT JOIN post_counts pc ON pc.forum_slug = f.slug
+    LEFT JOIN user_drift ud ON ud.forum_slug = f.slug
+WHERE f.posts IS DISTINCT FROM COALESCE(pc.count, 0)
+   OR f.threads IS DISTINCT FROM COALESCE(tc.count, 0)
+   OR ud.forum_slug IS NOT NULL
+ORDER BY f.slug;
+
+-- name: ListThreadVoteDrift :many
+SELECT t.id,
+       t.forum_slug,
+       COALESCE(t.votes, 0)::INT AS votes,
+       COALESCE(v.sum, 0)::INT   AS actual_votes
+FROM threads t
+    LEFT JOIN (SELECT thread_id, SUM(voice) AS sum FROM votes GROUP BY thread_id) v ON v.thread_id = t.id
+WHERE t.votes IS DISTINCT FROM COALESCE(v.sum, 0)
+ORDER BY t.id;
+
+-- name: ListPostVoteDrift :many
+SELECT p.id,
+       p.thread_id,
+       p.votes,
+       COALESCE(v.sum, 0)::INT AS actual_votes
+FROM posts p
+    LEFT JOIN (SELECT post_id, SUM(voice) AS sum FROM post_votes GROUP BY post_id) v ON v.post_id = p.id
+WHERE p.votes <> COALESCE(v.sum, 0)
+ORDER BY p.id;
+
+-- name: ListPostPathDrift :many
+-- Rebuilds path, root_id and sort_key from parent_id. Root posts have a
+-- NULL path, an empty one is accepted as well.
+WITH RECURSIVE tree AS (
+    SELECT id, NULL::INT[] AS path, id AS root_id, ARRAY [id] AS sort_key
+    FROM posts
+    WHERE parent_id IS NULL
+    UNION ALL
+    SELECT c.id, t.sort_key, t.root_id, t.sort_key || c.id
+    FROM posts c
+        JOIN tree t ON c.parent_id = t.id
+)
+SELECT p.id,
+       p.thread_id,
+       p.path,
+       t.path     AS expected_path,
+       p.root_id,
+       t.root_id  AS expected_root_id,
+       p.sort_key,
+       t.sort_key AS expected_sort_key
+FROM posts p
+    JOIN tree t ON t.id = p.id
+WHERE COALESCE(p.path, '{}') <> COALESCE(t.path, '{}')
+   OR p.root_id <> t.root_id
+   OR p.sort_key <> t.sort_key
+ORDER BY p.id;
+
+-- name: RepairForumCounters :execrows
+UPDATE forums f
+SET posts   = c.posts,
+    threads = c.threads
+FROM (SELECT s.slug,
+             (SELECT COUNT(*) FROM posts p JOIN threads t ON t.id = p.thread_id WHERE t.forum_slug = s.slug)::INT AS posts,
+             (SELECT COUNT(*) FROM threads t WHERE t.forum_slug = s.slug)::INT                                  AS threads
+      FROM forums s) c
+WHERE c.slug = f.slug
+  AND (f.posts IS DISTINCT FROM c.posts OR f.threads IS DISTINCT FROM c.threads);
+
+-- name: InsertMissingForumUsers :execrows
+INSERT INTO forum_user (forum_slug, user_id)
+SELECT t.forum_slug, u.id
+FROM threads t
+    JOIN users u ON u.nickname = t.user_nn
+UNION
+SELECT t.forum_slug, u.id
+FROM posts p
+    JOIN threads t ON t.id = p.thread_id
+    JOIN users u ON u.nickname = p.user_nn
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteExtraForumUsers :execrows
+DELETE
+FROM forum_user fu
+WHERE NOT EXISTS (SELECT 1
+                  FROM threads t
+                      JOIN users u ON u.nickname = t.user_nn
+                  WHERE t.forum_slug = fu.forum_slug
+                    AND u.id = fu.user_id)
+  AND NOT EXISTS (SELECT 1
+                  FROM posts p
+                      JOIN threads t ON t.id = p.thread_id
+                      JOIN users u ON u.nickname = p.user_nn
+                  WHERE t.forum_slug = fu.forum_slug
+                    AND u.id = fu.user_id);
+
+-- name: RepairThreadVotes :execrows
+UPDATE threads t
+SET votes = c.votes
+FROM (SELECT s.id, COALESCE(SUM(v.voice), 0)::INT AS votes
+      FROM threads s
+          LEFT JOIN votes v ON v.thread_id = s.id
+      GROUP BY s.id) c
+WHERE c.id = t.id
+  AND t.votes IS DISTINCT FROM c.votes;
+
+-- name: RepairPostVotes :execrows
+UPDATE posts p
+SET votes = c.votes
+FROM (SELECT s.id, COALESCE(SUM(v.voice), 0)::INT AS votes
+      FROM posts s
+          LEFT JOIN post_votes v ON v.post_id = s.id
+      GROUP BY s.id) c
+WHERE c.id = p.id
+  AND p.votes <> c.votes;
+
+-- name: RepairPostPaths :execrows
+WITH RECURSIVE tree AS (
+    SELECT id, NULL::INT[] AS path, id AS root_id, ARRAY [id] AS sort_key
+    FROM posts
+    WHERE parent_id IS NULL
+    UNION ALL
+    SELECT c.id, t.sort_key, t.root_id, t.sort_key || c.id
+    FROM posts c
+        JOIN tree t ON c.parent_id = t.id
+)
+UPDATE posts p
+SET path     = t.path,
+    root_id  = t.root_id,
+    sort_key = t.sort_key
+FROM tree t
+WHERE t.id = p.id
+  AND (COALESCE(p.path, '{}') <> COALESCE(t.path, '{}')
+    OR p.root_id <> t.root_id
+    OR p.sort_key <> t.sort_key);
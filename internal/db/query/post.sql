@@ -1,9 +1,213 @@
 -- name: CreatePosts :batchone
-INSERT INTO posts (message, parent_id, user_nn, thread_id, path)
-VALUES ($1, $2, $3, $4, $5)
+INSERT INTO posts (id, message, parent_id, user_nn, thread_id, path, root_id, sort_key)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 RETURNING *;
 
+-- name: CopyPosts :copyfrom
+INSERT INTO posts (id, message, parent_id, user_nn, thread_id, path, root_id, sort_key)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8);
+
 -- name: ListByID :many
 SELECT *
 FROM posts
-WHERE id = ANY($1::int[]);
\ No newline at end of file
+WHERE id = ANY($1::int[]);
+
+-- name: GetPostFull :one
+SELECT sqlc.embed(p), sqlc.embed(u), sqlc.embed(t), sqlc.embed(f)
+FROM posts p
+    JOIN users u ON p.user_nn = u.nickname
+    JOIN threads t ON p.thread_id = t.id
+    JOIN forums f ON t.forum_slug = f.slug
+WHERE p.id = $1;
+
+-- name: UpdatePostMessage :exec
+UPDATE posts
+SET message = $1, isedited = TRUE
+WHERE id = $2;
+
+-- name: ListPostRevisions :many
+SELECT *
+FROM post_revisions
+WHERE post_id = $1
+ORDER BY id;
+
+-- name: CountPostRevisions :one
+SELECT COUNT(*)::INT AS count
+FROM post_revisions
+WHERE post_id = $1;
+
+-- name: ListPostsFlat :many
+-- A cursor continues after (after_created, after_id) in the order of the
+-- page. Ids are taken after the transaction start that sets created, so
+-- they don't follow that order and since, the id of a post, is only kept
+-- as the filter it always was.
+SELECT *
+FROM posts
+WHERE thread_id = sqlc.arg(thread_id)
+  AND (sqlc.narg(since)::INT IS NULL OR id > sqlc.narg(since)::INT)
+  AND (sqlc.narg(after_created)::TIMESTAMPTZ IS NULL
+    OR (created, id) > (sqlc.narg(after_created)::TIMESTAMPTZ, sqlc.narg(after_id)::INT))
+ORDER BY created, id
+LIMIT sqlc.arg('limit');
+
+-- name: ListPostsFlatDesc :many
+SELECT *
+FROM posts
+WHERE thread_id = sqlc.arg(thread_id)
+  AND (sqlc.narg(since)::INT IS NULL OR id < sqlc.narg(since)::INT)
+  AND (sqlc.narg(after_created)::TIMESTAMPTZ IS NULL
+    OR (created, id) < (sqlc.narg(after_created)::TIMESTAMPTZ, sqlc.narg(after_id)::INT))
+ORDER BY created DESC, id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: ListPostsFlatTop :many
+-- Orders the thread's posts by votes, highest first, then by id, without
+-- regard to the tree. The page starts after a rank key, [-votes, id], taken
+-- from a cursor or from a since post that is looked up. Reads
+-- posts__thread_votes_id.
+WITH anchor AS (
+    SELECT COALESCE(sqlc.narg(after_rank)::INT[], (SELECT ARRAY [-s.votes, s.id] FROM posts s WHERE s.id = sqlc.narg(since)::INT)) AS rank_key
+)
+SELECT sqlc.embed(p)
+FROM posts p, anchor a
+WHERE p.thread_id = sqlc.arg(thread_id)
+  AND (sqlc.narg(since)::INT IS NULL AND sqlc.narg(after_rank)::INT[] IS NULL
+    OR p.votes < -a.rank_key[1]
+    OR p.votes = -a.rank_key[1] AND p.id > a.rank_key[2])
+ORDER BY p.votes DESC, p.id
+LIMIT sqlc.arg('limit');
+
+-- name: ListPostsFlatTopDesc :many
+WITH anchor AS (
+    SELECT COALESCE(sqlc.narg(after_rank)::INT[], (SELECT ARRAY [-s.votes, s.id] FROM posts s WHERE s.id = sqlc.narg(since)::INT)) AS rank_key
+)
+SELECT sqlc.embed(p)
+FROM posts p, anchor a
+WHERE p.thread_id = sqlc.arg(thread_id)
+  AND (sqlc.narg(since)::INT IS NULL AND sqlc.narg(after_rank)::INT[] IS NULL
+    OR p.votes > -a.rank_key[1]
+    OR p.votes = -a.rank_key[1] AND p.id < a.rank_key[2])
+ORDER BY p.votes, p.id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: ListPostsTree :many
+-- The page starts after an anchor, given either as the sort key of a
+-- cursor or as the id of a since post that is looked up.
+SELECT *
+FROM posts
+WHERE thread_id = sqlc.arg(thread_id)
+  AND (sqlc.narg(since)::INT IS NULL AND sqlc.narg(after_path)::INT[] IS NULL
+    OR sort_key > COALESCE(sqlc.narg(after_path)::INT[], (SELECT s.sort_key FROM posts s WHERE s.id = sqlc.narg(since)::INT)))
+ORDER BY sort_key
+LIMIT sqlc.arg('limit');
+
+-- name: ListPostsTreeDesc :many
+SELECT *
+FROM posts
+WHERE thread_id = sqlc.arg(thread_id)
+  AND (sqlc.narg(since)::INT IS NULL AND sqlc.narg(after_path)::INT[] IS NULL
+    OR sort_key < COALESCE(sqlc.narg(after_path)::INT[], (SELECT s.sort_key FROM posts s WHERE s.id = sqlc.narg(since)::INT)))
+ORDER BY sort_key DESC
+LIMIT sqlc.arg('limit');
+
+-- name: ListPostsParentTree :many
+-- limit counts root posts. After an anchor, given as in ListPostsTree, the
+-- page finishes the anchor's tree before taking limit more trees.
+WITH anchor AS (
+    SELECT COALESCE(sqlc.narg(after_path)::INT[], (SELECT s.sort_key FROM posts s WHERE s.id = sqlc.narg(since)::INT)) AS sort_key
+),
+roots AS (
+    SELECT r.id
+    FROM posts r, anchor a
+    WHERE r.thread_id = sqlc.arg(thread_id)
+      AND r.parent_id IS NULL
+      AND (sqlc.narg(since)::INT IS NULL AND sqlc.narg(after_path)::INT[] IS NULL OR r.id > a.sort_key[1])
+    ORDER BY r.id
+    LIMIT sqlc.arg('limit')
+)
+SELECT sqlc.embed(p)
+FROM posts p, anchor a
+WHERE p.thread_id = sqlc.arg(thread_id)
+  AND (p.root_id IN (SELECT id FROM roots)
+    OR p.root_id = a.sort_key[1] AND p.sort_key > a.sort_key)
+ORDER BY p.root_id, p.sort_key;
+
+-- name: ListPostsParentTreeDesc :many
+WITH anchor AS (
+    SELECT COALESCE(sqlc.narg(after_path)::INT[], (SELECT s.sort_key FROM posts s WHERE s.id = sqlc.narg(since)::INT)) AS sort_key
+),
+roots AS (
+    SELECT r.id
+    FROM posts r, anchor a
+    WHERE r.thread_id = sqlc.arg(thread_id)
+      AND r.parent_id IS NULL
+      AND (sqlc.narg(since)::INT IS NULL AND sqlc.narg(after_path)::INT[] IS NULL OR r.id < a.sort_key[1])
+    ORDER BY r.id DESC
+    LIMIT sqlc.arg('limit')
+)
+SELECT sqlc.embed(p)
+FROM posts p, anchor a
+WHERE p.thread_id = sqlc.arg(thread_id)
+  AND (p.root_id IN (SELECT id FROM roots)
+    OR p.root_id = a.sort_key[1] AND p.sort_key > a.sort_key)
+ORDER BY p.root_id DESC, p.sort_key;
+
+-- name: NextPostIDs :many
+SELECT nextval(pg_get_serial_sequence('posts', 'id'))::INT AS id
+FROM generate_series(1, sqlc.arg(count)::INT);
+
+-- name: ListPostsTop :many
+-- Orders the thread depth first, ranking the posts of every level by votes,
+-- highest first, then by id. rank_key holds -votes and the id of every post
+-- on the path to the post, so it sorts like a sort key. The page starts
+-- after the rank key of a cursor or of a since post.
+--
+-- Ranks depend on votes, so no index holds them: every page walks and sorts
+-- the whole thread, whatever the limit. ListPostsFlatTop pages through an
+-- index instead.
+WITH RECURSIVE ranked AS (
+    SELECT r.id, ARRAY [-r.votes, r.id] AS rank_key
+    FROM posts r
+    WHERE r.thread_id = sqlc.arg(thread_id)
+      AND r.parent_id IS NULL
+    UNION ALL
+    SELECT c.id, parent.rank_key || ARRAY [-c.votes, c.id]
+    FROM posts c
+        JOIN ranked parent ON c.parent_id = parent.id
+    WHERE c.thread_id = sqlc.arg(thread_id)
+),
+anchor AS (
+    SELECT COALESCE(sqlc.narg(after_rank)::INT[], (SELECT s.rank_key FROM ranked s WHERE s.id = sqlc.narg(since)::INT)) AS rank_key
+)
+SELECT sqlc.embed(p), r.rank_key
+FROM ranked r
+    JOIN posts p ON p.id = r.id,
+    anchor a
+WHERE sqlc.narg(since)::INT IS NULL AND sqlc.narg(after_rank)::INT[] IS NULL
+   OR r.rank_key > a.rank_key
+ORDER BY r.rank_key
+LIMIT sqlc.arg('limit');
+
+-- name: ListPostsTopDesc :many
+WITH RECURSIVE ranked AS (
+    SELECT r.id, ARRAY [-r.votes, r.id] AS rank_key
+    FROM posts r
+    WHERE r.thread_id = sqlc.arg(thread_id)
+      AND r.parent_id IS NULL
+    UNION ALL
+    SELECT c.id, parent.rank_key || ARRAY [-c.votes, c.id]
+    FROM posts c
+        JOIN ranked parent ON c.parent_id = parent.id
+    WHERE c.thread_id = sqlc.arg(thread_id)
+),
+anchor AS (
+    SELECT COALESCE(sqlc.narg(after_rank)::INT[], (SELECT s.rank_key FROM ranked s WHERE s.id = sqlc.narg(since)::INT)) AS rank_key
+)
+SELECT sqlc.embed(p), r.rank_key
+FROM ranked r
+    JOIN posts p ON p.id = r.id,
+    anchor a
+WHERE sqlc.narg(since)::INT IS NULL AND sqlc.narg(after_rank)::INT[] IS NULL
+   OR r.rank_key < a.rank_key
+ORDER BY r.rank_key DESC
+LIMIT sqlc.arg('limit');
@@ -0,0 +1,8 @@
+-- name: GetStatus :one
+SELECT (SELECT COUNT(*) FROM forums)::INT  AS forum,
+       (SELECT COUNT(*) FROM posts)::INT   AS post,
+       (SELECT COUNT(*) FROM threads)::INT AS thread,
+       (SELECT COUNT(*) FROM users)::INT   AS "user";
+
+-- name: ClearAll :exec
+TRUNCATE votes, post_votes, post_revisions, thread_revisions, posts, threads, forums, users, forum_user;
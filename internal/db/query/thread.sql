@@ -7,3 +7,49 @@ WHERE slug = $1;
 SELECT *
 FROM threads
 WHERE id = $1;
+
+-- name: CreateThread :one
+INSERT INTO threads (slug, created, title, message, user_nn, forum_slug)
+VALUES (sqlc.narg(slug), COALESCE(sqlc.narg(created), CURRENT_TIMESTAMP), sqlc.arg(title), sqlc.narg(message), sqlc.arg(user_nn),
+        (SELECT slug FROM forums WHERE slug = sqlc.arg(forum)))
+RETURNING *;
+
+-- name: ListThreadsByForum :many
+SELECT *
+FROM threads
+WHERE forum_slug = sqlc.arg(forum_slug)
+  AND (sqlc.narg(since)::TIMESTAMPTZ IS NULL OR created >= sqlc.narg(since)::TIMESTAMPTZ)
+  AND (sqlc.narg(after_created)::TIMESTAMPTZ IS NULL
+    OR (created, id) > (sqlc.narg(after_created)::TIMESTAMPTZ, sqlc.narg(after_id)::INT))
+ORDER BY created, id
+LIMIT sqlc.arg('limit');
+
+-- name: ListThreadsByForumDesc :many
+SELECT *
+FROM threads
+WHERE forum_slug = sqlc.arg(forum_slug)
+  AND (sqlc.narg(since)::TIMESTAMPTZ IS NULL OR created <= sqlc.narg(since)::TIMESTAMPTZ)
+  AND (sqlc.narg(after_created)::TIMESTAMPTZ IS NULL
+    OR (created, id) < (sqlc.narg(after_created)::TIMESTAMPTZ, sqlc.narg(after_id)::INT))
+ORDER BY created DESC, id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: UpdateThreadByID :one
+UPDATE threads
+SET title   = COALESCE(sqlc.narg(title), title),
+    message = COALESCE(sqlc.narg(message), message)
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: UpdateThreadBySlug :one
+UPDATE threads
+SET title   = COALESCE(sqlc.narg(title), title),
+    message = COALESCE(sqlc.narg(message), message)
+WHERE slug = sqlc.arg(slug)
+RETURNING *;
+
+-- name: ListThreadRevisions :many
+SELECT *
+FROM thread_revisions
+WHERE thread_id = $1
+ORDER BY id;
@@ -1,2 +1,35 @@
 -- name: GetUserByNickname :one
-SELECT * FROM users WHERE nickname = $1;
\ No newline at end of file
+SELECT * FROM users WHERE nickname = $1;
+
+-- name: GetUserByEmail :one
+SELECT * FROM users WHERE email = $1;
+
+-- name: CreateUser :exec
+INSERT INTO users (nickname, fullname, email, about)
+VALUES ($1, $2, $3, $4);
+
+-- name: UpdateUser :one
+UPDATE users
+SET fullname = COALESCE(sqlc.narg(fullname), fullname),
+    email    = COALESCE(sqlc.narg(email), email),
+    about    = COALESCE(sqlc.narg(about), about)
+WHERE nickname = sqlc.arg(nickname)
+RETURNING *;
+
+-- name: ListUsersByForum :many
+SELECT u.*
+FROM forum_user fu
+    JOIN users u ON fu.user_id = u.id
+WHERE fu.forum_slug = sqlc.arg(forum_slug)
+  AND (sqlc.narg(since)::CITEXT IS NULL OR u.nickname > sqlc.narg(since)::CITEXT)
+ORDER BY u.nickname
+LIMIT sqlc.arg('limit');
+
+-- name: ListUsersByForumDesc :many
+SELECT u.*
+FROM forum_user fu
+    JOIN users u ON fu.user_id = u.id
+WHERE fu.forum_slug = sqlc.arg(forum_slug)
+  AND (sqlc.narg(since)::CITEXT IS NULL OR u.nickname < sqlc.narg(since)::CITEXT)
+ORDER BY u.nickname DESC
+LIMIT sqlc.arg('limit');
@@ -0,0 +1,65 @@
+-- name: UpsertVote :one
+-- Returns the voice the user had before, 0 if they had not voted.
+WITH previous AS (
+    SELECT voice
+    FROM votes
+    WHERE thread_id = $1
+      AND user_nn = $2
+)
+INSERT INTO votes (thread_id, user_nn, voice)
+VALUES ($1, $2, $3)
+ON CONFLICT ON CONSTRAINT votes_thread_user_unique
+    DO UPDATE SET voice = EXCLUDED.voice
+RETURNING COALESCE((SELECT voice FROM previous), 0)::INT AS previous;
+
+-- name: DeleteVote :one
+DELETE
+FROM votes
+WHERE thread_id = $1
+  AND user_nn = $2
+RETURNING voice;
+
+-- name: GetVote :one
+SELECT *
+FROM votes
+WHERE thread_id = $1
+  AND user_nn = $2;
+
+-- name: ListVotesByThread :many
+SELECT *
+FROM votes
+WHERE thread_id = sqlc.arg(thread_id)
+  AND (sqlc.narg(voice)::INT IS NULL OR voice = sqlc.narg(voice)::INT)
+  AND (sqlc.narg(since)::CITEXT IS NULL OR user_nn > sqlc.narg(since)::CITEXT)
+ORDER BY user_nn
+LIMIT sqlc.arg('limit');
+
+-- name: ListVotesByThreadDesc :many
+SELECT *
+FROM votes
+WHERE thread_id = sqlc.arg(thread_id)
+  AND (sqlc.narg(voice)::INT IS NULL OR voice = sqlc.narg(voice)::INT)
+  AND (sqlc.narg(since)::CITEXT IS NULL OR user_nn < sqlc.narg(since)::CITEXT)
+ORDER BY user_nn DESC
+LIMIT sqlc.arg('limit');
+
+-- name: UpsertPostVote :one
+-- Returns the voice the user had before, 0 if they had not voted.
+WITH previous AS (
+    SELECT voice
+    FROM post_votes
+    WHERE post_id = $1
+      AND user_nn = $2
+)
+INSERT INTO post_votes (post_id, user_nn, voice)
+VALUES ($1, $2, $3)
+ON CONFLICT ON CONSTRAINT post_votes_post_user_unique
+    DO UPDATE SET voice = EXCLUDED.voice
+RETURNING COALESCE((SELECT voice FROM previous), 0)::INT AS previous;
+
+-- name: DeletePostVote :one
+DELETE
+FROM post_votes
+WHERE post_id = $1
+  AND user_nn = $2
+RETURNING voice;
@@ -11,6 +11,45 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const createThread = `-- name: CreateThread :one
+INSERT INTO threads (slug, created, title, message, user_nn, forum_slug)
+VALUES ($1, COALESCE($2, CURRENT_TIMESTAMP), $3, $4, $5,
+        (SELECT slug FROM forums WHERE slug = $6))
+RETURNING id, slug, created, title, message, votes, user_nn, forum_slug
+`
+
+type CreateThreadParams struct {
+	Slug    pgtype.Text
+	Created pgtype.Timestamptz
+	Title   string
+	Message pgtype.Text
+	UserNn  string
+	Forum   string
+}
+
+func (q *Queries) CreateThread(ctx context.Context, arg CreateThreadParams) (Thread, error) {
+	row := q.db.QueryRow(ctx, createThread,
+		arg.Slug,
+		arg.Created,
+		arg.Title,
+		arg.Message,
+		arg.UserNn,
+		arg.Forum,
+	)
+	var i Thread
+	err := row.Scan(
+		&i.ID,
+		&i.Slug,
+		&i.Created,
+		&i.Title,
+		&i.Message,
+		&i.Votes,
+		&i.UserNn,
+		&i.ForumSlug,
+	)
+	return i, err
+}
+
 const getThreadByID = `-- name: GetThreadByID :one
 SELECT id, slug, created, title, message, votes, user_nn, forum_slug
 FROM threads
@@ -54,3 +93,204 @@ func (q *Queries) GetThreadBySlug(ctx context.Context, slug pgtype.Text) (Thread
 	)
 	return i, err
 }
+
+const listThreadRevisions = `-- name: ListThreadRevisions :many
+SELECT id, thread_id, title, message, edited
+FROM thread_revisions
+WHERE thread_id = $1
+ORDER BY id
+`
+
+func (q *Queries) ListThreadRevisions(ctx context.Context, threadID int32) ([]ThreadRevision, error) {
+	rows, err := q.db.Query(ctx, listThreadRevisions, threadID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ThreadRevision
+	for rows.Next() {
+		var i ThreadRevision
+		if err := rows.Scan(
+			&i.ID,
+			&i.ThreadID,
+			&i.Title,
+			&i.Message,
+			&i.Edited,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listThreadsByForum = `-- name: ListThreadsByForum :many
+SELECT id, slug, created, title, message, votes, user_nn, forum_slug
+FROM threads
+WHERE forum_slug = $1
+  AND ($2::TIMESTAMPTZ IS NULL OR created >= $2::TIMESTAMPTZ)
+  AND ($3::TIMESTAMPTZ IS NULL
+    OR (created, id) > ($3::TIMESTAMPTZ, $4::INT))
+ORDER BY created, id
+LIMIT $5
+`
+
+type ListThreadsByForumParams struct {
+	ForumSlug    string
+	Since        pgtype.Timestamptz
+	AfterCreated pgtype.Timestamptz
+	AfterID      pgtype.Int4
+	Limit        int32
+}
+
+func (q *Queries) ListThreadsByForum(ctx context.Context, arg ListThreadsByForumParams) ([]Thread, error) {
+	rows, err := q.db.Query(ctx, listThreadsByForum,
+		arg.ForumSlug,
+		arg.Since,
+		arg.AfterCreated,
+		arg.AfterID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Thread
+	for rows.Next() {
+		var i Thread
+		if err := rows.Scan(
+			&i.ID,
+			&i.Slug,
+			&i.Created,
+			&i.Title,
+			&i.Message,
+			&i.Votes,
+			&i.UserNn,
+			&i.ForumSlug,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listThreadsByForumDesc = `-- name: ListThreadsByForumDesc :many
+SELECT id, slug, created, title, message, votes, user_nn, forum_slug
+FROM threads
+WHERE forum_slug = $1
+  AND ($2::TIMESTAMPTZ IS NULL OR created <= $2::TIMESTAMPTZ)
+  AND ($3::TIMESTAMPTZ IS NULL
+    OR (created, id) < ($3::TIMESTAMPTZ, $4::INT))
+ORDER BY created DESC, id DESC
+LIMIT $5
+`
+
+type ListThreadsByForumDescParams struct {
+	ForumSlug    string
+	Since        pgtype.Timestamptz
+	AfterCreated pgtype.Timestamptz
+	AfterID      pgtype.Int4
+	Limit        int32
+}
+
+func (q *Queries) ListThreadsByForumDesc(ctx context.Context, arg ListThreadsByForumDescParams) ([]Thread, error) {
+	rows, err := q.db.Query(ctx, listThreadsByForumDesc,
+		arg.ForumSlug,
+		arg.Since,
+		arg.AfterCreated,
+		arg.AfterID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Thread
+	for rows.Next() {
+		var i Thread
+		if err := rows.Scan(
+			&i.ID,
+			&i.Slug,
+			&i.Created,
+			&i.Title,
+			&i.Message,
+			&i.Votes,
+			&i.UserNn,
+			&i.ForumSlug,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateThreadByID = `-- name: UpdateThreadByID :one
+UPDATE threads
+SET title   = COALESCE($1, title),
+    message = COALESCE($2, message)
+WHERE id = $3
+RETURNING id, slug, created, title, message, votes, user_nn, forum_slug
+`
+
+type UpdateThreadByIDParams struct {
+	Title   pgtype.Text
+	Message pgtype.Text
+	ID      int32
+}
+
+func (q *Queries) UpdateThreadByID(ctx context.Context, arg UpdateThreadByIDParams) (Thread, error) {
+	row := q.db.QueryRow(ctx, updateThreadByID, arg.Title, arg.Message, arg.ID)
+	var i Thread
+	err := row.Scan(
+		&i.ID,
+		&i.Slug,
+		&i.Created,
+		&i.Title,
+		&i.Message,
+		&i.Votes,
+		&i.UserNn,
+		&i.ForumSlug,
+	)
+	return i, err
+}
+
+const updateThreadBySlug = `-- name: UpdateThreadBySlug :one
+UPDATE threads
+SET title   = COALESCE($1, title),
+    message = COALESCE($2, message)
+WHERE slug = $3
+RETURNING id, slug, created, title, message, votes, user_nn, forum_slug
+`
+
+type UpdateThreadBySlugParams struct {
+	Title   pgtype.Text
+	Message pgtype.Text
+	Slug    pgtype.Text
+}
+
+func (q *Queries) UpdateThreadBySlug(ctx context.Context, arg UpdateThreadBySlugParams) (Thread, error) {
+	row := q.db.QueryRow(ctx, updateThreadBySlug, arg.Title, arg.Message, arg.Slug)
+	var i Thread
+	err := row.Scan(
+		&i.ID,
+		&i.Slug,
+		&i.Created,
+		&i.Title,
+		&i.Message,
+		&i.Votes,
+		&i.UserNn,
+		&i.ForumSlug,
+	)
+	return i, err
+}
@@ -7,8 +7,49 @@ package db
 
 import (
 	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const createUser = `-- name: CreateUser :exec
+INSERT INTO users (nickname, fullname, email, about)
+VALUES ($1, $2, $3, $4)
+`
+
+type CreateUserParams struct {
+	Nickname string
+	Fullname string
+	Email    string
+	About    pgtype.Text
+}
+
+func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) error {
+	_, err := q.db.Exec(ctx, createUser,
+		arg.Nickname,
+		arg.Fullname,
+		arg.Email,
+		arg.About,
+	)
+	return err
+}
+
+const getUserByEmail = `-- name: GetUserByEmail :one
+SELECT id, nickname, fullname, email, about FROM users WHERE email = $1
+`
+
+func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error) {
+	row := q.db.QueryRow(ctx, getUserByEmail, email)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Nickname,
+		&i.Fullname,
+		&i.Email,
+		&i.About,
+	)
+	return i, err
+}
+
 const getUserByNickname = `-- name: GetUserByNickname :one
 SELECT id, nickname, fullname, email, about FROM users WHERE nickname = $1
 `
@@ -25,3 +66,121 @@ func (q *Queries) GetUserByNickname(ctx context.Context, nickname string) (User,
 	)
 	return i, err
 }
+
+const listUsersByForum = `-- name: ListUsersByForum :many
+SELECT u.id, u.nickname, u.fullname, u.email, u.about
+FROM forum_user fu
+    JOIN users u ON fu.user_id = u.id
+WHERE fu.forum_slug = $1
+  AND ($2::CITEXT IS NULL OR u.nickname > $2::CITEXT)
+ORDER BY u.nickname
+LIMIT $3
+`
+
+type ListUsersByForumParams struct {
+	ForumSlug string
+	Since     pgtype.Text
+	Limit     int32
+}
+
+func (q *Queries) ListUsersByForum(ctx context.Context, arg ListUsersByForumParams) ([]User, error) {
+	rows, err := q.db.Query(ctx, listUsersByForum, arg.ForumSlug, arg.Since, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []User
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.Nickname,
+			&i.Fullname,
+			&i.Email,
+			&i.About,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUsersByForumDesc = `-- name: ListUsersByForumDesc :many
+SELECT u.id, u.nickname, u.fullname, u.email, u.about
+FROM forum_user fu
+    JOIN users u ON fu.user_id = u.id
+WHERE fu.forum_slug = $1
+  AND ($2::CITEXT IS NULL OR u.nickname < $2::CITEXT)
+ORDER BY u.nickname DESC
+LIMIT $3
+`
+
+type ListUsersByForumDescParams struct {
+	ForumSlug string
+	Since     pgtype.Text
+	Limit     int32
+}
+
+func (q *Queries) ListUsersByForumDesc(ctx context.Context, arg ListUsersByForumDescParams) ([]User, error) {
+	rows, err := q.db.Query(ctx, listUsersByForumDesc, arg.ForumSlug, arg.Since, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []User
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.Nickname,
+			&i.Fullname,
+			&i.Email,
+			&i.About,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateUser = `-- name: UpdateUser :one
+UPDATE users
+SET fullname = COALESCE($1, fullname),
+    email    = COALESCE($2, email),
+    about    = COALESCE($3, about)
+WHERE nickname = $4
+RETURNING id, nickname, fullname, email, about
+`
+
+type UpdateUserParams struct {
+	Fullname pgtype.Text
+	Email    pgtype.Text
+	About    pgtype.Text
+	Nickname string
+}
+
+func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, error) {
+	row := q.db.QueryRow(ctx, updateUser,
+		arg.Fullname,
+		arg.Email,
+		arg.About,
+		arg.Nickname,
+	)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Nickname,
+		&i.Fullname,
+		&i.Email,
+		&i.About,
+	)
+	return i, err
+}
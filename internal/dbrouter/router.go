@@ -0,0 +1,86 @@
+package dbrouter
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/viewsharp/technopark-forum/internal/db"
+)
+
+// Header forces the reads of a request to the primary, for clients that need
+// to see their own writes right away.
+const Header = "X-Read-Primary"
+
+// key is the fasthttp user value marking requests whose reads may be served
+// by a replica. It has to be a string: fasthttp.RequestCtx.Value only
+// resolves string keys.
+const key = "read_replica"
+
+type userValueSetter interface {
+	SetUserValue(key string, value interface{})
+}
+
+// AllowReplica lets the reads of the request behind ctx go to a replica.
+func AllowReplica(ctx userValueSetter) {
+	ctx.SetUserValue(key, true)
+}
+
+func replicaAllowed(ctx context.Context) bool {
+	allowed, _ := ctx.Value(key).(bool)
+	return allowed
+}
+
+// DB is the primary connection: it has to start transactions as well.
+type DB interface {
+	db.DBTX
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+// Router sends every statement to the primary except the queries of
+// requests marked with AllowReplica, which are spread across the replicas
+// round-robin. Transactions, Exec, batches and COPY always run on the
+// primary.
+type Router struct {
+	primary  DB
+	replicas []db.DBTX
+	next     atomic.Uint32
+}
+
+func New(primary DB, replicas ...db.DBTX) *Router {
+	return &Router{primary: primary, replicas: replicas}
+}
+
+func (r *Router) read(ctx context.Context) db.DBTX {
+	if len(r.replicas) == 0 || !replicaAllowed(ctx) {
+		return r.primary
+	}
+	n := r.next.Add(1)
+	return r.replicas[int(n)%len(r.replicas)]
+}
+
+func (r *Router) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	return r.read(ctx).QueryRow(ctx, sql, args...)
+}
+
+func (r *Router) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	return r.read(ctx).Query(ctx, sql, args...)
+}
+
+func (r *Router) Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error) {
+	return r.primary.Exec(ctx, sql, arguments...)
+}
+
+func (r *Router) SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults {
+	return r.primary.SendBatch(ctx, b)
+}
+
+func (r *Router) CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error) {
+	return r.primary.CopyFrom(ctx, tableName, columnNames, rowSrc)
+}
+
+func (r *Router) Begin(ctx context.Context) (pgx.Tx, error) {
+	return r.primary.Begin(ctx)
+}
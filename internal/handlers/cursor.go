@@ -0,0 +1,24 @@
+package handlers
+
+import (
+	"github.com/valyala/fasthttp"
+
+	"github.com/viewsharp/technopark-forum/internal/cursor"
+)
+
+// readCursor decodes the cursor query parameter into position and reports
+// whether the request carried one.
+func readCursor(ctx *fasthttp.RequestCtx, position any) (bool, error) {
+	token := ctx.QueryArgs().Peek(cursor.Param)
+	if len(token) == 0 {
+		return false, nil
+	}
+	return true, cursor.Decode(string(token), position)
+}
+
+// setNextCursor hands out the cursor of the next page, if there is one.
+func setNextCursor[T any](ctx *fasthttp.RequestCtx, next *T) {
+	if next != nil {
+		ctx.Response.Header.Set(cursor.Header, cursor.Encode(next))
+	}
+}
@@ -1,5 +1,85 @@
 package handlers
 
+import (
+	"errors"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/viewsharp/technopark-forum/internal/validation"
+)
+
+// Error is an RFC 7807 problem details body served as
+// application/problem+json.
 type Error struct {
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Detail string `json:"detail,omitempty"`
+	Code   string `json:"code"`
+	Param  string `json:"param,omitempty"`
+
+	// Message repeats Detail for clients of the original {"message": ...}
+	// error contract.
 	Message string `json:"message"`
-}
\ No newline at end of file
+
+	InvalidParams validation.Errors `json:"invalid_params,omitempty"`
+}
+
+func (Error) ContentType() string {
+	return "application/problem+json"
+}
+
+var (
+	ErrMalformedBody = errors.New("malformed body")
+	ErrInvalidParam  = errors.New("invalid parameter")
+	ErrRouteNotFound = errors.New("route not found")
+	ErrInternal      = errors.New("internal error")
+)
+
+// NewError builds the problem for err from the code registered for it.
+// Errors without a registered code are reported as internal errors.
+func NewError(err error, detail string, param string) Error {
+	p := lookupProblem(err)
+
+	result := Error{
+		Type:    problemTypePrefix + p.code,
+		Title:   p.title,
+		Status:  p.status,
+		Detail:  detail,
+		Code:    p.code,
+		Param:   param,
+		Message: detail,
+	}
+
+	var fieldErrs validation.Errors
+	if errors.As(err, &fieldErrs) {
+		result.InvalidParams = fieldErrs
+	}
+
+	return result
+}
+
+func problem(err error, detail string, param string) (interface{}, int) {
+	result := NewError(err, detail, param)
+	return result, result.Status
+}
+
+func internalError(err error) (interface{}, int) {
+	return problem(err, err.Error(), "")
+}
+
+func invalidParam(param string, detail string) (interface{}, int) {
+	return problem(ErrInvalidParam, detail, param)
+}
+
+func malformedBody(err error) (interface{}, int) {
+	return problem(ErrMalformedBody, err.Error(), "body")
+}
+
+func invalidBody(err error) (interface{}, int) {
+	return problem(err, "Invalid request body", "body")
+}
+
+func routeNotFound(ctx *fasthttp.RequestCtx) (interface{}, int) {
+	return problem(ErrRouteNotFound, "Can't find route: "+string(ctx.Path()), "")
+}
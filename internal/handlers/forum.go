@@ -6,6 +6,7 @@ import (
 	"github.com/goccy/go-json"
 
 	forumUC "github.com/viewsharp/technopark-forum/internal/usecase/forum"
+	"github.com/viewsharp/technopark-forum/internal/validation"
 )
 
 type ForumHandler struct {
@@ -18,13 +19,17 @@ func NewForumHandler(storageBundle *UsecaseSet) *ForumHandler {
 
 func (fh *ForumHandler) Create(ctx *fasthttp.RequestCtx) (interface{}, int) {
 	if string(ctx.Path()) != "/api/forum/create" {
-		return nil, fasthttp.StatusNotFound
+		return routeNotFound(ctx)
 	}
 
 	var forum forumUC.Forum
 	err := json.Unmarshal(ctx.PostBody(), &forum)
 	if err != nil {
-		return nil, fasthttp.StatusBadRequest
+		return malformedBody(err)
+	}
+	err = validation.Struct(&forum)
+	if err != nil {
+		return invalidBody(err)
 	}
 
 	createdForum, err := fh.sb.forum.Add(ctx, forum)
@@ -36,9 +41,9 @@ func (fh *ForumHandler) Create(ctx *fasthttp.RequestCtx) (interface{}, int) {
 				return result, fasthttp.StatusConflict
 			}
 		case forumUC.ErrNotFoundUser:
-			return Error{Message: "Can't find user with nickname: " + *forum.User}, fasthttp.StatusNotFound
+			return problem(err, "Can't find user with nickname: "+*forum.User, "user")
 		}
-		return Error{Message: err.Error()}, fasthttp.StatusInternalServerError
+		return internalError(err)
 	}
 
 	return createdForum, fasthttp.StatusCreated
@@ -53,8 +58,8 @@ func (fh *ForumHandler) Get(ctx *fasthttp.RequestCtx) (interface{}, int) {
 	case nil:
 		return result, fasthttp.StatusOK
 	case forumUC.ErrNotFound:
-		return Error{Message: "Can't find forum by slug: " + slug}, fasthttp.StatusNotFound
+		return problem(err, "Can't find forum by slug: "+slug, "slug")
 	}
 
-	return Error{Message: err.Error()}, fasthttp.StatusInternalServerError
+	return internalError(err)
 }
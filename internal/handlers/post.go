@@ -11,6 +11,7 @@ import (
 
 	post2 "github.com/viewsharp/technopark-forum/internal/usecase/post"
 	"github.com/viewsharp/technopark-forum/internal/usecase/thread"
+	"github.com/viewsharp/technopark-forum/internal/validation"
 )
 
 type PostHandler struct {
@@ -25,7 +26,11 @@ func (ph *PostHandler) Create(ctx *fasthttp.RequestCtx) (interface{}, int) {
 	var posts []post2.Post
 	err := json.Unmarshal(ctx.PostBody(), &posts)
 	if err != nil {
-		return nil, fasthttp.StatusBadRequest
+		return malformedBody(err)
+	}
+	err = validation.Slice(posts)
+	if err != nil {
+		return invalidBody(err)
 	}
 
 	slugOrId := ctx.UserValue("slug_or_id").(string)
@@ -42,18 +47,10 @@ func (ph *PostHandler) Create(ctx *fasthttp.RequestCtx) (interface{}, int) {
 		case nil:
 			return posts, fasthttp.StatusCreated
 		case thread.ErrNotFound:
-			if threadIdParseErr == nil {
-				return Error{
-					Message: fmt.Sprintf("Can't find post thread by id: %d", threadId),
-				}, fasthttp.StatusNotFound
-			} else {
-				return Error{
-					Message: "Can't find post thread by slug: " + slugOrId,
-				}, fasthttp.StatusNotFound
-			}
+			return threadNotFound(err, slugOrId)
 		}
 
-		return Error{Message: err.Error()}, fasthttp.StatusInternalServerError
+		return internalError(err)
 	}
 
 	if threadIdParseErr == nil {
@@ -64,28 +61,18 @@ func (ph *PostHandler) Create(ctx *fasthttp.RequestCtx) (interface{}, int) {
 
 	if err != nil {
 		if errors.Is(err, post2.ErrInvalidParent) {
-			return Error{Message: "Parent post was created in another thread"}, fasthttp.StatusConflict
+			return problem(err, "Parent post was created in another thread", "parent")
 		}
 		if errors.Is(err, post2.ErrNotFoundThread) {
-			if threadIdParseErr == nil {
-				return Error{
-					Message: fmt.Sprintf("Can't find post thread by id: %d", threadId),
-				}, fasthttp.StatusNotFound
-			} else {
-				return Error{
-					Message: "Can't find post thread by slug: " + slugOrId,
-				}, fasthttp.StatusNotFound
-			}
+			return threadNotFound(err, slugOrId)
 		}
 
 		var errNotFoundUser post2.ErrNotFoundUser
 		if errors.As(err, &errNotFoundUser) {
-			return Error{
-				Message: "Can't find post author by nickname: " + errNotFoundUser.Nickname,
-			}, fasthttp.StatusNotFound
+			return problem(err, "Can't find post author by nickname: "+errNotFoundUser.Nickname, "author")
 		}
 
-		return Error{Message: err.Error()}, fasthttp.StatusInternalServerError
+		return internalError(err)
 	}
 
 	return posts, fasthttp.StatusCreated
@@ -95,7 +82,7 @@ func (ph *PostHandler) Get(ctx *fasthttp.RequestCtx) (interface{}, int) {
 	idString := ctx.UserValue("id").(string)
 	postId, err := strconv.Atoi(idString)
 	if err != nil {
-		return nil, fasthttp.StatusBadRequest
+		return invalidParam("id", "id must be an integer")
 	}
 
 	var result *post2.PostFull
@@ -112,25 +99,23 @@ func (ph *PostHandler) Get(ctx *fasthttp.RequestCtx) (interface{}, int) {
 	case nil:
 		return result, fasthttp.StatusOK
 	case post2.ErrNotFound:
-		return Error{
-			Message: "Can't find user by nickname: ",
-		}, fasthttp.StatusNotFound
+		return problem(err, fmt.Sprintf("Can't find post with id: %d", postId), "id")
 	}
 
-	return nil, fasthttp.StatusInternalServerError
+	return internalError(err)
 }
 
 func (ph *PostHandler) GetByThread(ctx *fasthttp.RequestCtx) (interface{}, int) {
 	slugOrId := ctx.UserValue("slug_or_id").(string)
 	threadId, threadIdParseErr := strconv.Atoi(slugOrId)
 
-	limit := 1000
+	limit := ph.sb.cfg.API.DefaultLimit
 	limitParam := ctx.QueryArgs().Peek("limit")
 	if limitParam != nil {
 		var err error
 		limit, err = strconv.Atoi(string(limitParam))
 		if err != nil {
-			return nil, fasthttp.StatusBadRequest
+			return invalidParam("limit", "limit must be an integer")
 		}
 	}
 
@@ -146,85 +131,108 @@ func (ph *PostHandler) GetByThread(ctx *fasthttp.RequestCtx) (interface{}, int)
 		var err error
 		since, err = strconv.Atoi(string(sinceParam))
 		if err != nil {
-			return nil, fasthttp.StatusBadRequest
+			return invalidParam("since", "since must be a post id")
 		}
 	}
 
-	var err error
-	var posts []post2.Post
-	switch string(ctx.QueryArgs().Peek("sort")) {
-	case "tree":
-		if threadIdParseErr == nil {
-			posts, err = ph.sb.post.TreeByThreadId(ctx, threadId, limit, desc, since)
-		} else {
-			posts, err = ph.sb.post.TreeByThreadSlug(ctx, slugOrId, limit, desc, since)
-		}
-	case "parent_tree":
-		if threadIdParseErr == nil {
-			posts, err = ph.sb.post.ParentTreeByThreadId(ctx, threadId, limit, desc, since)
-		} else {
-			posts, err = ph.sb.post.ParentTreeByThreadSlug(ctx, slugOrId, limit, desc, since)
-		}
-	default:
-		if threadIdParseErr == nil {
-			posts, err = ph.sb.post.FlatByThreadId(ctx, threadId, limit, desc, since)
-		} else {
-			posts, err = ph.sb.post.FlatByThreadSlug(ctx, slugOrId, limit, desc, since)
+	page := post2.Page{Sort: post2.SortFlat, Desc: desc, Since: since, Limit: limit}
+	switch sort := string(ctx.QueryArgs().Peek("sort")); sort {
+	case post2.SortTree, post2.SortParentTree, post2.SortTop, post2.SortFlatTop:
+		page.Sort = sort
+	}
+
+	var after post2.Cursor
+	hasCursor, err := readCursor(ctx, &after)
+	if err != nil {
+		return invalidParam("cursor", "cursor is malformed")
+	}
+	if hasCursor {
+		switch {
+		case sinceParam != nil:
+			return invalidParam("cursor", "cursor cannot be combined with since")
+		case after.Sort != page.Sort:
+			return invalidParam("cursor", "cursor was issued for sort="+after.Sort)
+		case after.Desc != desc:
+			return invalidParam("cursor", "cursor was issued for the other sort order")
+		case after.Sort == post2.SortFlat && (after.ID <= 0 || after.Created == nil),
+			after.Sort == post2.SortFlatTop && len(after.Path) != 2,
+			after.Sort != post2.SortFlat && len(after.Path) == 0:
+			return invalidParam("cursor", "cursor is malformed")
 		}
+		page.After = &after
+	}
+
+	var posts []post2.Post
+	var next *post2.Cursor
+	if threadIdParseErr == nil {
+		posts, next, err = ph.sb.post.ByThreadId(ctx, threadId, page)
+	} else {
+		posts, next, err = ph.sb.post.ByThreadSlug(ctx, slugOrId, page)
 	}
 
 	switch err {
 	case nil:
+		setNextCursor(ctx, next)
 		return posts, fasthttp.StatusOK
 	case post2.ErrNotFoundThread:
-		if threadIdParseErr == nil {
-			return Error{
-				Message: fmt.Sprintf("Can't find thread by slug: %d", threadId),
-			}, fasthttp.StatusNotFound
-		} else {
-			return Error{
-				Message: "Can't find thread by slug: " + slugOrId,
-			}, fasthttp.StatusNotFound
-		}
+		return threadNotFound(err, slugOrId)
 	}
 
-	return nil, fasthttp.StatusInternalServerError
+	return internalError(err)
 }
 
 func (ph *PostHandler) Update(ctx *fasthttp.RequestCtx) (interface{}, int) {
 	var obj post2.PostUpdate
 	err := json.Unmarshal(ctx.PostBody(), &obj)
 	if err != nil {
-		return nil, fasthttp.StatusBadRequest
+		return malformedBody(err)
+	}
+	err = validation.Struct(&obj)
+	if err != nil {
+		return invalidBody(err)
 	}
 
 	idString := ctx.UserValue("id").(string)
 	postId, err := strconv.Atoi(idString)
 	if err != nil {
-		return nil, fasthttp.StatusBadRequest
+		return invalidParam("id", "id must be an integer")
 	}
 
 	result, err := ph.sb.post.ById(ctx, postId, nil)
 	switch err {
 	case nil:
-		var err error = nil
-		if obj.Message != nil {
-			if *result.Post.Message != *obj.Message {
-				err = ph.sb.post.UpdateById(ctx, postId, obj)
-				result.Post.IsEdited = new(bool)
-				*result.Post.IsEdited = true
-				result.Post.Message = obj.Message
+		if obj.Message != nil && *result.Post.Message != *obj.Message {
+			err = ph.sb.post.UpdateById(ctx, postId, obj)
+			if err != nil {
+				return internalError(err)
 			}
+			result.Post.IsEdited = new(bool)
+			*result.Post.IsEdited = true
+			result.Post.Message = obj.Message
 		}
+		return result.Post, fasthttp.StatusOK
+	case post2.ErrNotFound:
+		return problem(err, fmt.Sprintf("Can't find post with id: %d", postId), "id")
+	}
 
-		if err == nil {
-			return result.Post, fasthttp.StatusOK
-		}
+	return internalError(err)
+}
+
+// Revisions lists the earlier versions of the post, oldest first.
+func (ph *PostHandler) Revisions(ctx *fasthttp.RequestCtx) (interface{}, int) {
+	postId, err := strconv.Atoi(ctx.UserValue("id").(string))
+	if err != nil {
+		return invalidParam("id", "id must be an integer")
+	}
+
+	result, err := ph.sb.post.Revisions(ctx, postId)
+
+	switch err {
+	case nil:
+		return result, fasthttp.StatusOK
 	case post2.ErrNotFound:
-		return Error{
-			Message: fmt.Sprintf("Can't find post with id: %d", postId),
-		}, fasthttp.StatusNotFound
+		return problem(err, fmt.Sprintf("Can't find post with id: %d", postId), "id")
 	}
 
-	return nil, fasthttp.StatusInternalServerError
+	return internalError(err)
 }
@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/viewsharp/technopark-forum/internal/usecase/forum"
+	"github.com/viewsharp/technopark-forum/internal/usecase/post"
+	"github.com/viewsharp/technopark-forum/internal/usecase/thread"
+	"github.com/viewsharp/technopark-forum/internal/usecase/user"
+	"github.com/viewsharp/technopark-forum/internal/usecase/vote"
+	"github.com/viewsharp/technopark-forum/internal/validation"
+)
+
+const problemTypePrefix = "urn:technopark-forum:problem:"
+
+// Stable machine-readable error codes. Clients match on these, so existing
+// values must never change meaning.
+const (
+	CodeMalformedBody    = "malformed_body"
+	CodeValidationFailed = "validation_failed"
+	CodeInvalidParam     = "invalid_parameter"
+	CodeRouteNotFound    = "route_not_found"
+	CodeInternal         = "internal_error"
+
+	CodeForumNotFound  = "forum_not_found"
+	CodeForumExists    = "forum_exists"
+	CodeThreadNotFound = "thread_not_found"
+	CodeThreadExists   = "thread_exists"
+	CodePostNotFound   = "post_not_found"
+	CodeInvalidParent  = "invalid_parent"
+	CodeUserNotFound   = "user_not_found"
+	CodeUserConflict   = "user_conflict"
+)
+
+type problemKind struct {
+	code   string
+	status int
+	title  string
+}
+
+type problemEntry struct {
+	match func(err error) bool
+	problemKind
+}
+
+var (
+	problemInternal = problemKind{CodeInternal, fasthttp.StatusInternalServerError, "Internal server error"}
+
+	problemRegistry []problemEntry
+)
+
+// registerProblem maps a sentinel error to exactly one problem kind.
+func registerProblem(sentinel error, code string, status int, title string) {
+	for _, entry := range problemRegistry {
+		if entry.match(sentinel) {
+			panic("handlers: error registered twice: " + sentinel.Error())
+		}
+	}
+	problemRegistry = append(problemRegistry, problemEntry{
+		match:       func(err error) bool { return errors.Is(err, sentinel) },
+		problemKind: problemKind{code, status, title},
+	})
+}
+
+// registerProblemType maps every error of type T to exactly one problem kind.
+func registerProblemType[T error](code string, status int, title string) {
+	problemRegistry = append(problemRegistry, problemEntry{
+		match: func(err error) bool {
+			var target T
+			return errors.As(err, &target)
+		},
+		problemKind: problemKind{code, status, title},
+	})
+}
+
+func lookupProblem(err error) problemKind {
+	for _, entry := range problemRegistry {
+		if entry.match(err) {
+			return entry.problemKind
+		}
+	}
+	return problemInternal
+}
+
+func init() {
+	registerProblem(ErrMalformedBody, CodeMalformedBody, fasthttp.StatusBadRequest, "Malformed request body")
+	registerProblemType[validation.Errors](CodeValidationFailed, fasthttp.StatusBadRequest, "Request body validation failed")
+	registerProblem(ErrInvalidParam, CodeInvalidParam, fasthttp.StatusBadRequest, "Invalid parameter")
+	registerProblem(ErrRouteNotFound, CodeRouteNotFound, fasthttp.StatusNotFound, "Route not found")
+	registerProblem(ErrInternal, CodeInternal, fasthttp.StatusInternalServerError, "Internal server error")
+
+	registerProblem(forum.ErrNotFound, CodeForumNotFound, fasthttp.StatusNotFound, "Forum not found")
+	registerProblem(forum.ErrNotFoundUser, CodeUserNotFound, fasthttp.StatusNotFound, "User not found")
+	registerProblem(forum.ErrUniqueViolation, CodeForumExists, fasthttp.StatusConflict, "Forum already exists")
+
+	registerProblem(thread.ErrNotFound, CodeThreadNotFound, fasthttp.StatusNotFound, "Thread not found")
+	registerProblem(thread.ErrNotFoundForum, CodeForumNotFound, fasthttp.StatusNotFound, "Forum not found")
+	registerProblem(thread.ErrNotFoundUser, CodeUserNotFound, fasthttp.StatusNotFound, "User not found")
+	registerProblem(thread.ErrUniqueViolation, CodeThreadExists, fasthttp.StatusConflict, "Thread already exists")
+
+	registerProblem(post.ErrInvalidParent, CodeInvalidParent, fasthttp.StatusConflict, "Invalid parent post")
+	registerProblem(post.ErrNotFoundThread, CodeThreadNotFound, fasthttp.StatusNotFound, "Thread not found")
+	registerProblem(post.ErrNotFound, CodePostNotFound, fasthttp.StatusNotFound, "Post not found")
+	registerProblemType[post.ErrNotFoundUser](CodeUserNotFound, fasthttp.StatusNotFound, "User not found")
+
+	registerProblem(user.ErrUniqueViolation, CodeUserConflict, fasthttp.StatusConflict, "User data conflicts with an existing user")
+	registerProblem(user.ErrNotFound, CodeUserNotFound, fasthttp.StatusNotFound, "User not found")
+	registerProblem(user.ErrNotFoundForum, CodeForumNotFound, fasthttp.StatusNotFound, "Forum not found")
+
+	registerProblem(vote.ErrNotFoundPost, CodePostNotFound, fasthttp.StatusNotFound, "Post not found")
+	registerProblem(vote.ErrNotFoundThread, CodeThreadNotFound, fasthttp.StatusNotFound, "Thread not found")
+	registerProblem(vote.ErrNotFoundUser, CodeUserNotFound, fasthttp.StatusNotFound, "User not found")
+}
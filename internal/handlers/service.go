@@ -1,10 +1,6 @@
 package handlers
 
-import (
-	"github.com/valyala/fasthttp"
-
-	"github.com/viewsharp/technopark-forum/internal/usecase/status"
-)
+import "github.com/valyala/fasthttp"
 
 type ServiceHandler struct {
 	sb *UsecaseSet
@@ -15,26 +11,28 @@ func NewServiceHandler(storageBundle *UsecaseSet) *ServiceHandler {
 }
 
 func (fh *ServiceHandler) Status(ctx *fasthttp.RequestCtx) (interface{}, int) {
-	var result status.Status
-	err := fh.sb.DB().QueryRow(ctx, `	SELECT (SELECT COUNT(*) FROM forums),
-											(SELECT COUNT(*) FROM posts),
-											(SELECT COUNT(*) FROM threads),
-											(SELECT COUNT(*) FROM users);`,
-	).Scan(&result.Forum, &result.Post, &result.Thread, &result.User)
+	result, err := fh.sb.status.Get(ctx)
 	if err != nil {
-		return Error{
-			Message: err.Error(),
-		}, fasthttp.StatusInternalServerError
+		return internalError(err)
 	}
 	return result, fasthttp.StatusOK
 }
 
 func (fh *ServiceHandler) Clear(ctx *fasthttp.RequestCtx) (interface{}, int) {
-	_, err := fh.sb.DB().Exec(ctx, "TRUNCATE votes, posts, threads, forums, users, forum_user")
+	err := fh.sb.status.Clear(ctx)
 	if err != nil {
-		return Error{
-			Message: err.Error(),
-		}, fasthttp.StatusInternalServerError
+		return internalError(err)
 	}
 	return nil, fasthttp.StatusOK
 }
+
+// Check reports where the denormalized counters, forum users and post paths
+// drifted from the source tables. It never repairs them: a repair locks the
+// tables against every write until it finishes, so it is left to forumcheck.
+func (fh *ServiceHandler) Check(ctx *fasthttp.RequestCtx) (interface{}, int) {
+	result, err := fh.sb.check.Run(ctx, false)
+	if err != nil {
+		return internalError(err)
+	}
+	return result, fasthttp.StatusOK
+}
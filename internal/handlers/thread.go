@@ -1,13 +1,14 @@
 package handlers
 
 import (
-	"fmt"
 	"strconv"
+	"time"
 
 	"github.com/goccy/go-json"
 	"github.com/valyala/fasthttp"
 
 	thread2 "github.com/viewsharp/technopark-forum/internal/usecase/thread"
+	"github.com/viewsharp/technopark-forum/internal/validation"
 )
 
 type ThreadHandler struct {
@@ -24,9 +25,13 @@ func (th *ThreadHandler) Create(ctx *fasthttp.RequestCtx) (interface{}, int) {
 	var obj thread2.Thread
 	err := json.Unmarshal(ctx.PostBody(), &obj)
 	if err != nil {
-		return nil, fasthttp.StatusBadRequest
+		return malformedBody(err)
 	}
 	obj.Forum = &slug
+	err = validation.Struct(&obj)
+	if err != nil {
+		return invalidBody(err)
+	}
 
 	err = th.sb.thread.Add(ctx, &obj)
 	switch err {
@@ -38,24 +43,24 @@ func (th *ThreadHandler) Create(ctx *fasthttp.RequestCtx) (interface{}, int) {
 			return result, fasthttp.StatusConflict
 		}
 	case thread2.ErrNotFoundUser:
-		return Error{Message: "Can't find thread author by nickname: " + *obj.Author}, fasthttp.StatusNotFound
+		return problem(err, "Can't find thread author by nickname: "+*obj.Author, "author")
 	case thread2.ErrNotFoundForum:
-		return Error{Message: "Can't find thread forum by slug: " + *obj.Forum}, fasthttp.StatusNotFound
+		return problem(err, "Can't find thread forum by slug: "+*obj.Forum, "slug")
 	}
 
-	return Error{Message: err.Error()}, fasthttp.StatusInternalServerError
+	return internalError(err)
 }
 
 func (th *ThreadHandler) GetByForum(ctx *fasthttp.RequestCtx) (interface{}, int) {
 	slug := ctx.UserValue("slug").(string)
 
-	limit := 1000
+	limit := th.sb.cfg.API.DefaultLimit
 	limitParam := ctx.QueryArgs().Peek("limit")
 	if limitParam != nil {
 		var err error
 		limit, err = strconv.Atoi(string(limitParam))
 		if err != nil {
-			return nil, fasthttp.StatusBadRequest
+			return invalidParam("limit", "limit must be an integer")
 		}
 	}
 
@@ -65,18 +70,43 @@ func (th *ThreadHandler) GetByForum(ctx *fasthttp.RequestCtx) (interface{}, int)
 		desc = string(descParam) == "true"
 	}
 
-	since := string(ctx.QueryArgs().Peek("since"))
+	page := thread2.Page{Desc: desc, Limit: limit}
 
-	result, err := th.sb.thread.ByForumSlug(ctx, slug, desc, since, limit)
+	sinceParam := ctx.QueryArgs().Peek("since")
+	if len(sinceParam) > 0 {
+		since, err := time.Parse(time.RFC3339Nano, string(sinceParam))
+		if err != nil {
+			return invalidParam("since", "since must be an RFC 3339 timestamp")
+		}
+		page.Since = &since
+	}
+
+	var after thread2.Cursor
+	hasCursor, err := readCursor(ctx, &after)
+	if err != nil {
+		return invalidParam("cursor", "cursor is malformed")
+	}
+	if hasCursor {
+		if page.Since != nil {
+			return invalidParam("cursor", "cursor cannot be combined with since")
+		}
+		if after.Desc != desc {
+			return invalidParam("cursor", "cursor was issued for the other sort order")
+		}
+		page.After = &after
+	}
+
+	result, next, err := th.sb.thread.ByForumSlug(ctx, slug, page)
 
 	switch err {
 	case nil:
+		setNextCursor(ctx, next)
 		return result, fasthttp.StatusOK
 	case thread2.ErrNotFoundForum:
-		return Error{Message: "Can't find forum by slug: " + slug}, fasthttp.StatusNotFound
+		return problem(err, "Can't find forum by slug: "+slug, "slug")
 	}
 
-	return nil, fasthttp.StatusInternalServerError
+	return internalError(err)
 }
 
 func (th *ThreadHandler) Get(ctx *fasthttp.RequestCtx) (interface{}, int) {
@@ -94,57 +124,65 @@ func (th *ThreadHandler) Get(ctx *fasthttp.RequestCtx) (interface{}, int) {
 	case nil:
 		return result, fasthttp.StatusOK
 	case thread2.ErrNotFound:
-		if threadIdParseErr == nil {
-			return Error{
-				Message: fmt.Sprintf("Can't find thread by id: %d", threadId),
-			}, fasthttp.StatusNotFound
-		} else {
-			return Error{
-				Message: "Can't find thread by slug: " + slugOrId,
-			}, fasthttp.StatusNotFound
-		}
+		return threadNotFound(err, slugOrId)
 	}
 
-	return nil, fasthttp.StatusInternalServerError
+	return internalError(err)
 }
 
 func (th *ThreadHandler) Update(ctx *fasthttp.RequestCtx) (interface{}, int) {
 	var obj thread2.ThreadUpdate
 	err := json.Unmarshal(ctx.PostBody(), &obj)
 	if err != nil {
-		return nil, fasthttp.StatusBadRequest
+		return malformedBody(err)
 	}
 
+	var result *thread2.Thread
 	slugOrId := ctx.UserValue("slug_or_id").(string)
 	threadId, threadIdErr := strconv.Atoi(slugOrId)
 	if threadIdErr == nil {
-		err = th.sb.thread.UpdateById(ctx, threadId, &obj)
+		result, err = th.sb.thread.UpdateById(ctx, threadId, &obj)
 	} else {
-		err = th.sb.thread.UpdateBySlug(ctx, slugOrId, &obj)
+		result, err = th.sb.thread.UpdateBySlug(ctx, slugOrId, &obj)
 	}
 
-	if err == nil {
-		var result *thread2.Thread
-		if threadIdErr == nil {
-			result, err = th.sb.thread.ById(ctx, threadId)
-		} else {
-			result, err = th.sb.thread.BySlug(ctx, slugOrId)
-		}
+	switch err {
+	case nil:
+		return result, fasthttp.StatusOK
+	case thread2.ErrNotFound:
+		return threadNotFound(err, slugOrId)
+	}
 
-		switch err {
-		case nil:
-			return result, fasthttp.StatusOK
-		case thread2.ErrNotFound:
-			if threadIdErr == nil {
-				return Error{
-					Message: fmt.Sprintf("Can't find thread by id: %d", threadId),
-				}, fasthttp.StatusNotFound
-			} else {
-				return Error{
-					Message: "Can't find thread by slug: " + slugOrId,
-				}, fasthttp.StatusNotFound
-			}
-		}
+	return internalError(err)
+}
+
+// Revisions lists the earlier versions of the thread, oldest first.
+func (th *ThreadHandler) Revisions(ctx *fasthttp.RequestCtx) (interface{}, int) {
+	var result thread2.ThreadRevisions
+	var err error
+	slugOrId := ctx.UserValue("slug_or_id").(string)
+	threadId, threadIdParseErr := strconv.Atoi(slugOrId)
+	if threadIdParseErr == nil {
+		result, err = th.sb.thread.RevisionsById(ctx, threadId)
+	} else {
+		result, err = th.sb.thread.RevisionsBySlug(ctx, slugOrId)
+	}
+
+	switch err {
+	case nil:
+		return result, fasthttp.StatusOK
+	case thread2.ErrNotFound:
+		return threadNotFound(err, slugOrId)
+	}
+
+	return internalError(err)
+}
+
+// threadNotFound reports a thread addressed by the slug_or_id path parameter
+// that does not exist.
+func threadNotFound(err error, slugOrId string) (interface{}, int) {
+	if _, parseErr := strconv.Atoi(slugOrId); parseErr == nil {
+		return problem(err, "Can't find thread by id: "+slugOrId, "slug_or_id")
 	}
-	return nil, fasthttp.StatusInternalServerError
+	return problem(err, "Can't find thread by slug: "+slugOrId, "slug_or_id")
 }
@@ -1,43 +1,41 @@
 package handlers
 
 import (
-	"context"
-
-	"github.com/jackc/pgx/v5"
-	"github.com/jackc/pgx/v5/pgconn"
-
+	"github.com/viewsharp/technopark-forum/internal/cache"
+	"github.com/viewsharp/technopark-forum/internal/config"
 	"github.com/viewsharp/technopark-forum/internal/db"
+	"github.com/viewsharp/technopark-forum/internal/txmanager"
+	"github.com/viewsharp/technopark-forum/internal/usecase/check"
 	"github.com/viewsharp/technopark-forum/internal/usecase/forum"
 	"github.com/viewsharp/technopark-forum/internal/usecase/post"
+	"github.com/viewsharp/technopark-forum/internal/usecase/status"
 	"github.com/viewsharp/technopark-forum/internal/usecase/thread"
 	"github.com/viewsharp/technopark-forum/internal/usecase/user"
 	"github.com/viewsharp/technopark-forum/internal/usecase/vote"
 )
 
-type DB interface {
-	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
-	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
-	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
-}
-
 type UsecaseSet struct {
+	cfg *config.Config
+
+	check  *check.Usecase
 	forum  *forum.Usecase
 	post   *post.Usecase
+	status *status.Usecase
 	thread *thread.Usecase
 	user   *user.Usecase
 	vote   *vote.Usecase
 }
 
-func NewUsecaseSet(db DB, queries *db.Queries) *UsecaseSet {
+func NewUsecaseSet(queries *db.Queries, entities *cache.Entities, tx *txmanager.Manager, cfg *config.Config) *UsecaseSet {
 	return &UsecaseSet{
-		forum:  &forum.Usecase{DB: db, Queries: queries},
-		post:   &post.Usecase{DB: db, Queries: queries},
-		thread: &thread.Usecase{DB: db},
-		user:   &user.Usecase{DB: db},
-		vote:   &vote.Usecase{DB: db},
-	}
-}
+		cfg: cfg,
 
-func (sb *UsecaseSet) DB() DB {
-	return sb.forum.DB
+		check:  &check.Usecase{Tx: tx},
+		forum:  &forum.Usecase{Queries: queries, Cache: entities},
+		post:   &post.Usecase{Queries: queries, Cache: entities, Tx: tx, CopyThreshold: cfg.Postgres.CopyThreshold},
+		status: &status.Usecase{Queries: queries, Cache: entities},
+		thread: &thread.Usecase{Queries: queries, Cache: entities},
+		user:   &user.Usecase{Queries: queries, Cache: entities},
+		vote:   &vote.Usecase{Queries: queries, Cache: entities},
+	}
 }
@@ -7,6 +7,7 @@ import (
 	"github.com/valyala/fasthttp"
 
 	user2 "github.com/viewsharp/technopark-forum/internal/usecase/user"
+	"github.com/viewsharp/technopark-forum/internal/validation"
 )
 
 type UserHandler struct {
@@ -21,11 +22,15 @@ func (uh *UserHandler) Create(ctx *fasthttp.RequestCtx) (interface{}, int) {
 	var obj user2.User
 	err := json.Unmarshal(ctx.PostBody(), &obj)
 	if err != nil {
-		return Error{Message: err.Error()}, fasthttp.StatusBadRequest
+		return malformedBody(err)
 	}
 
 	nickname := ctx.UserValue("nickname").(string)
 	obj.Nickname = &nickname
+	err = validation.Struct(&obj)
+	if err != nil {
+		return invalidBody(err)
+	}
 
 	err = uh.sb.user.Add(ctx, &obj)
 	switch err {
@@ -51,7 +56,7 @@ func (uh *UserHandler) Create(ctx *fasthttp.RequestCtx) (interface{}, int) {
 		return result, fasthttp.StatusConflict
 	}
 
-	return Error{Message: err.Error()}, fasthttp.StatusInternalServerError
+	return internalError(err)
 }
 
 func (uh *UserHandler) Get(ctx *fasthttp.RequestCtx) (interface{}, int) {
@@ -63,12 +68,10 @@ func (uh *UserHandler) Get(ctx *fasthttp.RequestCtx) (interface{}, int) {
 	case nil:
 		return result, fasthttp.StatusOK
 	case user2.ErrNotFound:
-		return Error{
-			Message: "Can't find user by nickname: " + nickname,
-		}, fasthttp.StatusNotFound
+		return problem(err, "Can't find user by nickname: "+nickname, "nickname")
 	}
 
-	return Error{Message: err.Error()}, fasthttp.StatusInternalServerError
+	return internalError(err)
 }
 
 func (uh *UserHandler) Update(ctx *fasthttp.RequestCtx) (interface{}, int) {
@@ -77,7 +80,11 @@ func (uh *UserHandler) Update(ctx *fasthttp.RequestCtx) (interface{}, int) {
 	var obj user2.UserUpdate
 	err := json.Unmarshal(ctx.PostBody(), &obj)
 	if err != nil {
-		return nil, fasthttp.StatusBadRequest
+		return malformedBody(err)
+	}
+	err = validation.Struct(&obj)
+	if err != nil {
+		return invalidBody(err)
 	}
 
 	err = uh.sb.user.UpdateByNickname(ctx, nickname, &obj)
@@ -91,27 +98,23 @@ func (uh *UserHandler) Update(ctx *fasthttp.RequestCtx) (interface{}, int) {
 			Nickname: &nickname,
 		}, fasthttp.StatusOK
 	case user2.ErrUniqueViolation:
-		return Error{
-			Message: "This email is already registered by user: " + *obj.Email,
-		}, fasthttp.StatusConflict
+		return problem(err, "This email is already registered by user: "+*obj.Email, "email")
 	case user2.ErrNotFound:
-		return Error{
-			Message: "Can't find user by nickname: " + nickname,
-		}, fasthttp.StatusNotFound
+		return problem(err, "Can't find user by nickname: "+nickname, "nickname")
 	}
-	return Error{Message: err.Error()}, fasthttp.StatusInternalServerError
+	return internalError(err)
 }
 
 func (uh *UserHandler) GetByForum(ctx *fasthttp.RequestCtx) (interface{}, int) {
 	slug := ctx.UserValue("slug").(string)
 
-	limit := 1000
+	limit := uh.sb.cfg.API.DefaultLimit
 	limitParam := ctx.QueryArgs().Peek("limit")
 	if limitParam != nil {
 		var err error
 		limit, err = strconv.Atoi(string(limitParam))
 		if err != nil {
-			return nil, fasthttp.StatusBadRequest
+			return invalidParam("limit", "limit must be an integer")
 		}
 	}
 
@@ -121,16 +124,36 @@ func (uh *UserHandler) GetByForum(ctx *fasthttp.RequestCtx) (interface{}, int) {
 		desc = string(descParam) == "true"
 	}
 
-	since := string(ctx.QueryArgs().Peek("since"))
+	page := user2.Page{
+		Desc:  desc,
+		Since: string(ctx.QueryArgs().Peek("since")),
+		Limit: limit,
+	}
+
+	var after user2.Cursor
+	hasCursor, err := readCursor(ctx, &after)
+	if err != nil {
+		return invalidParam("cursor", "cursor is malformed")
+	}
+	if hasCursor {
+		if page.Since != "" {
+			return invalidParam("cursor", "cursor cannot be combined with since")
+		}
+		if after.Desc != desc {
+			return invalidParam("cursor", "cursor was issued for the other sort order")
+		}
+		page.After = &after
+	}
 
-	result, err := uh.sb.user.ByForumSlug(ctx, slug, desc, since, limit)
+	result, next, err := uh.sb.user.ByForumSlug(ctx, slug, page)
 
 	switch err {
 	case nil:
+		setNextCursor(ctx, next)
 		return result, fasthttp.StatusOK
 	case user2.ErrNotFoundForum:
-		return Error{Message: "Can't find forum by slug: " + slug}, fasthttp.StatusNotFound
+		return problem(err, "Can't find forum by slug: "+slug, "slug")
 	}
 
-	return Error{Message: err.Error()}, fasthttp.StatusInternalServerError
+	return internalError(err)
 }
@@ -9,6 +9,7 @@ import (
 
 	"github.com/viewsharp/technopark-forum/internal/usecase/thread"
 	vote2 "github.com/viewsharp/technopark-forum/internal/usecase/vote"
+	"github.com/viewsharp/technopark-forum/internal/validation"
 )
 
 type VoteHandler struct {
@@ -23,53 +24,178 @@ func (vh *VoteHandler) Create(ctx *fasthttp.RequestCtx) (interface{}, int) {
 	var obj vote2.Vote
 	err := json.Unmarshal(ctx.PostBody(), &obj)
 	if err != nil {
-		return nil, fasthttp.StatusBadRequest
+		return malformedBody(err)
+	}
+	err = validation.Struct(&obj)
+	if err != nil {
+		return invalidBody(err)
 	}
 
 	var result *thread.Thread
 	slugOrId := ctx.UserValue("slug_or_id").(string)
-	threadId, err := strconv.Atoi(slugOrId)
-
-	if err == nil {
-		err = vh.sb.vote.AddByThreadId(ctx, &obj, threadId)
-		switch err {
-		case nil:
-			result, err = vh.sb.thread.ById(ctx, threadId)
-		case vote2.ErrNotFoundThread:
-			return Error{
-				Message: fmt.Sprintf("Can't find thread by id: %d", threadId),
-			}, fasthttp.StatusNotFound
-		case vote2.ErrNotFoundUser:
-			return Error{
-				Message: "Can't find user by nickname: " + *obj.Nickname,
-			}, fasthttp.StatusNotFound
-
-		default:
-			return nil, fasthttp.StatusInternalServerError
-		}
+	threadId, threadIdParseErr := strconv.Atoi(slugOrId)
+
+	if threadIdParseErr == nil {
+		_, err = vh.sb.vote.AddByThreadId(ctx, &obj, threadId)
 	} else {
-		err = vh.sb.vote.AddByThreadSlug(ctx, &obj, slugOrId)
-		switch err {
-		case nil:
-			result, err = vh.sb.thread.BySlug(ctx, slugOrId)
-		case vote2.ErrNotFoundThread:
-			return Error{
-				Message: "Can't find user by nickname: " + *obj.Nickname,
-			}, fasthttp.StatusNotFound
-		case vote2.ErrNotFoundUser:
-			return Error{
-				Message: fmt.Sprintf("Can't find thread by id: %d", threadId),
-			}, fasthttp.StatusNotFound
-
-		default:
-			return nil, fasthttp.StatusInternalServerError
+		_, err = vh.sb.vote.AddByThreadSlug(ctx, &obj, slugOrId)
+	}
+
+	switch err {
+	case nil:
+	case vote2.ErrNotFoundThread:
+		return threadNotFound(err, slugOrId)
+	case vote2.ErrNotFoundUser:
+		return problem(err, "Can't find user by nickname: "+*obj.Nickname, "nickname")
+	default:
+		return internalError(err)
+	}
+
+	if threadIdParseErr == nil {
+		result, err = vh.sb.thread.ById(ctx, threadId)
+	} else {
+		result, err = vh.sb.thread.BySlug(ctx, slugOrId)
+	}
+	if err != nil {
+		return internalError(err)
+	}
+
+	return result, fasthttp.StatusOK
+}
+
+// CreateForPost votes on a post and returns it with its updated score.
+func (vh *VoteHandler) CreateForPost(ctx *fasthttp.RequestCtx) (interface{}, int) {
+	id, err := strconv.Atoi(ctx.UserValue("id").(string))
+	if err != nil {
+		return invalidParam("id", "id must be an integer")
+	}
+
+	var obj vote2.Vote
+	err = json.Unmarshal(ctx.PostBody(), &obj)
+	if err != nil {
+		return malformedBody(err)
+	}
+	err = validation.Struct(&obj)
+	if err != nil {
+		return invalidBody(err)
+	}
+
+	_, err = vh.sb.vote.AddByPostId(ctx, &obj, id)
+	switch err {
+	case nil:
+	case vote2.ErrNotFoundPost:
+		return problem(err, fmt.Sprintf("Can't find post with id: %d", id), "id")
+	case vote2.ErrNotFoundUser:
+		return problem(err, "Can't find user by nickname: "+*obj.Nickname, "nickname")
+	default:
+		return internalError(err)
+	}
+
+	result, err := vh.sb.post.ById(ctx, id, nil)
+	if err != nil {
+		return internalError(err)
+	}
+	return result.Post, fasthttp.StatusOK
+}
+
+func (vh *VoteHandler) GetByThread(ctx *fasthttp.RequestCtx) (interface{}, int) {
+	slugOrId := ctx.UserValue("slug_or_id").(string)
+
+	limit := vh.sb.cfg.API.DefaultLimit
+	limitParam := ctx.QueryArgs().Peek("limit")
+	if limitParam != nil {
+		var err error
+		limit, err = strconv.Atoi(string(limitParam))
+		if err != nil {
+			return invalidParam("limit", "limit must be an integer")
+		}
+	}
+
+	desc := false
+	descParam := ctx.QueryArgs().Peek("desc")
+	if descParam != nil {
+		desc = string(descParam) == "true"
+	}
+
+	page := vote2.Page{
+		Desc:  desc,
+		Since: string(ctx.QueryArgs().Peek("since")),
+		Limit: limit,
+	}
+
+	voiceParam := ctx.QueryArgs().Peek("voice")
+	if len(voiceParam) > 0 {
+		voice, err := strconv.Atoi(string(voiceParam))
+		if err != nil || voice != -1 && voice != 1 {
+			return invalidParam("voice", "voice must be -1 or 1")
+		}
+		page.Voice = int32(voice)
+	}
+
+	var after vote2.Cursor
+	hasCursor, err := readCursor(ctx, &after)
+	if err != nil {
+		return invalidParam("cursor", "cursor is malformed")
+	}
+	if hasCursor {
+		if page.Since != "" {
+			return invalidParam("cursor", "cursor cannot be combined with since")
+		}
+		if after.Desc != desc {
+			return invalidParam("cursor", "cursor was issued for the other sort order")
+		}
+		if after.Voice != page.Voice {
+			return invalidParam("cursor", "cursor was issued for another voice filter")
 		}
+		page.After = &after
+	}
+
+	var result *vote2.Votes
+	var next *vote2.Cursor
+	threadId, threadIdParseErr := strconv.Atoi(slugOrId)
+	if threadIdParseErr == nil {
+		result, next, err = vh.sb.vote.ListByThreadId(ctx, threadId, page)
+	} else {
+		result, next, err = vh.sb.vote.ListByThreadSlug(ctx, slugOrId, page)
+	}
+
+	switch err {
+	case nil:
+		setNextCursor(ctx, next)
+		return result, fasthttp.StatusOK
+	case vote2.ErrNotFoundThread:
+		return threadNotFound(err, slugOrId)
+	}
+
+	return internalError(err)
+}
+
+// Get returns the user's vote on the thread, with a voice of 0 if they have
+// not voted.
+func (vh *VoteHandler) Get(ctx *fasthttp.RequestCtx) (interface{}, int) {
+	slugOrId := ctx.UserValue("slug_or_id").(string)
+	nickname := string(ctx.QueryArgs().Peek("nickname"))
+	if nickname == "" {
+		return invalidParam("nickname", "nickname is required")
+	}
+
+	var result *vote2.Vote
+	var err error
+	threadId, threadIdParseErr := strconv.Atoi(slugOrId)
+	if threadIdParseErr == nil {
+		result, err = vh.sb.vote.ByThreadId(ctx, threadId, nickname)
+	} else {
+		result, err = vh.sb.vote.ByThreadSlug(ctx, slugOrId, nickname)
 	}
 
 	switch err {
 	case nil:
 		return result, fasthttp.StatusOK
+	case vote2.ErrNotFoundThread:
+		return threadNotFound(err, slugOrId)
+	case vote2.ErrNotFoundUser:
+		return problem(err, "Can't find user by nickname: "+nickname, "nickname")
 	}
 
-	return nil, fasthttp.StatusInternalServerError
+	return internalError(err)
 }
@@ -0,0 +1,91 @@
+package metrics
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+type Counter struct {
+	value atomic.Uint64
+}
+
+func (c *Counter) Inc() {
+	c.value.Add(1)
+}
+
+func (c *Counter) Add(n uint64) {
+	c.value.Add(n)
+}
+
+func (c *Counter) Value() uint64 {
+	return c.value.Load()
+}
+
+// CounterVec is a set of counters partitioned by label values.
+type CounterVec struct {
+	Name   string
+	Help   string
+	Labels []string
+
+	mu       sync.RWMutex
+	counters map[string]*labeledCounter
+}
+
+type labeledCounter struct {
+	Counter
+	values []string
+}
+
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	return &CounterVec{
+		Name:     name,
+		Help:     help,
+		Labels:   labels,
+		counters: make(map[string]*labeledCounter),
+	}
+}
+
+// With returns the counter for the given label values, creating it on first
+// use. Values must be passed in the order of Labels.
+func (v *CounterVec) With(values ...string) *Counter {
+	key := strings.Join(values, "\xff")
+
+	v.mu.RLock()
+	counter, ok := v.counters[key]
+	v.mu.RUnlock()
+	if ok {
+		return &counter.Counter
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	counter, ok = v.counters[key]
+	if !ok {
+		counter = &labeledCounter{values: values}
+		v.counters[key] = counter
+	}
+	return &counter.Counter
+}
+
+func (v *CounterVec) WriteTo(b *bytes.Buffer) {
+	writeHeader(b, v.Name, v.Help, "counter")
+
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	for _, key := range sortedKeys(v.counters) {
+		counter := v.counters[key]
+		writeSample(b, v.Name, v.Labels, counter.values, "", "", float64(counter.Value()))
+	}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,112 @@
+package metrics
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// DefBuckets are latency buckets in seconds suited to API requests.
+var DefBuckets = []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+type Histogram struct {
+	upperBounds []float64
+	buckets     []atomic.Uint64
+	count       atomic.Uint64
+	sumBits     atomic.Uint64
+}
+
+func (h *Histogram) Observe(value float64) {
+	for i, upperBound := range h.upperBounds {
+		if value <= upperBound {
+			h.buckets[i].Add(1)
+			break
+		}
+	}
+	h.count.Add(1)
+
+	for {
+		oldBits := h.sumBits.Load()
+		newBits := math.Float64bits(math.Float64frombits(oldBits) + value)
+		if h.sumBits.CompareAndSwap(oldBits, newBits) {
+			return
+		}
+	}
+}
+
+// HistogramVec is a set of histograms with shared buckets partitioned by
+// label values.
+type HistogramVec struct {
+	Name    string
+	Help    string
+	Labels  []string
+	Buckets []float64
+
+	mu         sync.RWMutex
+	histograms map[string]*labeledHistogram
+}
+
+type labeledHistogram struct {
+	Histogram
+	values []string
+}
+
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	return &HistogramVec{
+		Name:       name,
+		Help:       help,
+		Labels:     labels,
+		Buckets:    buckets,
+		histograms: make(map[string]*labeledHistogram),
+	}
+}
+
+// With returns the histogram for the given label values, creating it on
+// first use. Values must be passed in the order of Labels.
+func (v *HistogramVec) With(values ...string) *Histogram {
+	key := strings.Join(values, "\xff")
+
+	v.mu.RLock()
+	histogram, ok := v.histograms[key]
+	v.mu.RUnlock()
+	if ok {
+		return &histogram.Histogram
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	histogram, ok = v.histograms[key]
+	if !ok {
+		histogram = &labeledHistogram{
+			Histogram: Histogram{
+				upperBounds: v.Buckets,
+				buckets:     make([]atomic.Uint64, len(v.Buckets)),
+			},
+			values: values,
+		}
+		v.histograms[key] = histogram
+	}
+	return &histogram.Histogram
+}
+
+func (v *HistogramVec) WriteTo(b *bytes.Buffer) {
+	writeHeader(b, v.Name, v.Help, "histogram")
+
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	for _, key := range sortedKeys(v.histograms) {
+		histogram := v.histograms[key]
+
+		var cumulative uint64
+		for i, upperBound := range histogram.upperBounds {
+			cumulative += histogram.buckets[i].Load()
+			writeSample(b, v.Name+"_bucket", v.Labels, histogram.values, "le", formatFloat(upperBound), float64(cumulative))
+		}
+		count := histogram.count.Load()
+		writeSample(b, v.Name+"_bucket", v.Labels, histogram.values, "le", "+Inf", float64(count))
+		writeSample(b, v.Name+"_sum", v.Labels, histogram.values, "", "", math.Float64frombits(histogram.sumBits.Load()))
+		writeSample(b, v.Name+"_count", v.Labels, histogram.values, "", "", float64(count))
+	}
+}
@@ -0,0 +1,68 @@
+package metrics
+
+import (
+	"bytes"
+	"sync"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// PoolStats exposes pgxpool.Stat of one or more pools labelled by pool name.
+type PoolStats struct {
+	mu    sync.RWMutex
+	names []string
+	pools []*pgxpool.Pool
+}
+
+func (p *PoolStats) Add(name string, pool *pgxpool.Pool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.names = append(p.names, name)
+	p.pools = append(p.pools, pool)
+}
+
+type poolStat struct {
+	name  string
+	help  string
+	typ   string
+	value func(stat *pgxpool.Stat) float64
+}
+
+var poolStatList = []poolStat{
+	{"pgxpool_acquired_conns", "Number of currently acquired connections.", "gauge",
+		func(s *pgxpool.Stat) float64 { return float64(s.AcquiredConns()) }},
+	{"pgxpool_idle_conns", "Number of currently idle connections.", "gauge",
+		func(s *pgxpool.Stat) float64 { return float64(s.IdleConns()) }},
+	{"pgxpool_constructing_conns", "Number of connections being established.", "gauge",
+		func(s *pgxpool.Stat) float64 { return float64(s.ConstructingConns()) }},
+	{"pgxpool_total_conns", "Total number of connections in the pool.", "gauge",
+		func(s *pgxpool.Stat) float64 { return float64(s.TotalConns()) }},
+	{"pgxpool_max_conns", "Maximum size of the pool.", "gauge",
+		func(s *pgxpool.Stat) float64 { return float64(s.MaxConns()) }},
+	{"pgxpool_acquires_total", "Number of successful acquires from the pool.", "counter",
+		func(s *pgxpool.Stat) float64 { return float64(s.AcquireCount()) }},
+	{"pgxpool_empty_acquires_total", "Number of acquires that had to wait for a connection.", "counter",
+		func(s *pgxpool.Stat) float64 { return float64(s.EmptyAcquireCount()) }},
+	{"pgxpool_canceled_acquires_total", "Number of acquires canceled by their context.", "counter",
+		func(s *pgxpool.Stat) float64 { return float64(s.CanceledAcquireCount()) }},
+	{"pgxpool_acquire_wait_seconds_total", "Total time spent acquiring connections.", "counter",
+		func(s *pgxpool.Stat) float64 { return s.AcquireDuration().Seconds() }},
+}
+
+func (p *PoolStats) WriteTo(b *bytes.Buffer) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	stats := make([]*pgxpool.Stat, len(p.pools))
+	for i, pool := range p.pools {
+		stats[i] = pool.Stat()
+	}
+
+	labels := []string{"pool"}
+	for _, poolStat := range poolStatList {
+		writeHeader(b, poolStat.name, poolStat.help, poolStat.typ)
+		for i, stat := range stats {
+			writeSample(b, poolStat.name, labels, []string{p.names[i]}, "", "", poolStat.value(stat))
+		}
+	}
+}
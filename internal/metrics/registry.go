@@ -0,0 +1,103 @@
+package metrics
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/valyala/fasthttp"
+)
+
+// Collector writes its samples in the Prometheus text exposition format.
+type Collector interface {
+	WriteTo(b *bytes.Buffer)
+}
+
+type Registry struct {
+	mu         sync.RWMutex
+	collectors []Collector
+}
+
+var Default = &Registry{}
+
+func (r *Registry) Register(collectors ...Collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.collectors = append(r.collectors, collectors...)
+}
+
+// Register adds c to the default registry and returns it, so metrics can be
+// declared and registered in one package level statement.
+func Register[C Collector](c C) C {
+	Default.Register(c)
+	return c
+}
+
+func (r *Registry) WriteTo(b *bytes.Buffer) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, collector := range r.collectors {
+		collector.WriteTo(b)
+	}
+}
+
+// Handler serves every registered metric for a Prometheus scrape.
+func (r *Registry) Handler(ctx *fasthttp.RequestCtx) {
+	var b bytes.Buffer
+	r.WriteTo(&b)
+
+	ctx.Response.Header.Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	ctx.SetStatusCode(fasthttp.StatusOK)
+	ctx.SetBody(b.Bytes())
+}
+
+func writeHeader(b *bytes.Buffer, name, help, typ string) {
+	b.WriteString("# HELP ")
+	b.WriteString(name)
+	b.WriteByte(' ')
+	b.WriteString(strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(help))
+	b.WriteString("\n# TYPE ")
+	b.WriteString(name)
+	b.WriteByte(' ')
+	b.WriteString(typ)
+	b.WriteByte('\n')
+}
+
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// writeSample writes one sample line. extraName and extraValue add a trailing
+// label such as the le label of histogram buckets when extraName is not empty.
+func writeSample(b *bytes.Buffer, name string, labels, values []string, extraName, extraValue string, value float64) {
+	b.WriteString(name)
+	if len(labels) > 0 || extraName != "" {
+		b.WriteByte('{')
+		for i, label := range labels {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			writeLabel(b, label, values[i])
+		}
+		if extraName != "" {
+			if len(labels) > 0 {
+				b.WriteByte(',')
+			}
+			writeLabel(b, extraName, extraValue)
+		}
+		b.WriteByte('}')
+	}
+	b.WriteByte(' ')
+	b.WriteString(formatFloat(value))
+	b.WriteByte('\n')
+}
+
+func writeLabel(b *bytes.Buffer, name, value string) {
+	b.WriteString(name)
+	b.WriteString(`="`)
+	b.WriteString(labelValueEscaper.Replace(value))
+	b.WriteByte('"')
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"time"
+
+	"github.com/valyala/fasthttp"
+	"go.uber.org/zap"
+
+	"github.com/viewsharp/technopark-forum/internal/requestid"
+)
+
+func AccessLog(logger *zap.Logger) Middleware {
+	return func(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+		return func(ctx *fasthttp.RequestCtx) {
+			t := time.Now()
+			next(ctx)
+			logger.Info(
+				"handled",
+				zap.Int("status", ctx.Response.Header.StatusCode()),
+				zap.ByteString("method", ctx.Method()),
+				zap.Duration("duration", time.Since(t)),
+				zap.ByteString("uri", ctx.Request.Header.RequestURI()),
+				zap.String("request_id", requestid.FromContext(ctx)),
+			)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/viewsharp/technopark-forum/internal/metrics"
+)
+
+// RouteKey is the user value under which the router stores the template of
+// the matched route, e.g. /api/thread/:slug_or_id/posts.
+const RouteKey = "route"
+
+var (
+	requestsTotal = metrics.Register(metrics.NewCounterVec(
+		"http_requests_total",
+		"Number of handled HTTP requests.",
+		"route", "method", "status", "sort",
+	))
+	requestDuration = metrics.Register(metrics.NewHistogramVec(
+		"http_request_duration_seconds",
+		"HTTP request latency.",
+		metrics.DefBuckets,
+		"route", "method", "status", "sort",
+	))
+)
+
+// Metrics records request count and latency by route template, method,
+// status and post listing sort mode.
+func Metrics(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+	return func(ctx *fasthttp.RequestCtx) {
+		t := time.Now()
+		next(ctx)
+		duration := time.Since(t)
+
+		route, _ := ctx.UserValue(RouteKey).(string)
+		if route == "" {
+			route = "unmatched"
+		}
+
+		labels := []string{
+			route,
+			string(ctx.Method()),
+			strconv.Itoa(ctx.Response.StatusCode()),
+			sortMode(ctx),
+		}
+		requestsTotal.With(labels...).Inc()
+		requestDuration.With(labels...).Observe(duration.Seconds())
+	}
+}
+
+// sortMode keeps the sort label bounded to the modes the API knows.
+func sortMode(ctx *fasthttp.RequestCtx) string {
+	switch sort := string(ctx.QueryArgs().Peek("sort")); sort {
+	case "", "flat", "tree", "parent_tree", "top", "flat_top":
+		return sort
+	default:
+		return "other"
+	}
+}
@@ -0,0 +1,17 @@
+package middleware
+
+import (
+	"github.com/valyala/fasthttp"
+)
+
+// Middleware wraps a handler with cross-cutting behaviour such as logging or
+// recovery. It must call next to continue the chain.
+type Middleware func(next fasthttp.RequestHandler) fasthttp.RequestHandler
+
+// Chain wraps handler so that the first middleware is the outermost one.
+func Chain(handler fasthttp.RequestHandler, middlewares ...Middleware) fasthttp.RequestHandler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
+	}
+	return handler
+}
@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"strconv"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/viewsharp/technopark-forum/internal/dbrouter"
+)
+
+// ReplicaReads lets GET and HEAD requests read from replicas unless they send
+// X-Read-Primary: true. Other methods write, and the reads that follow a
+// write have to see it, so they stay on the primary.
+func ReplicaReads(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+	return func(ctx *fasthttp.RequestCtx) {
+		if (ctx.IsGet() || ctx.IsHead()) && !forcePrimary(ctx) {
+			dbrouter.AllowReplica(ctx)
+		}
+		next(ctx)
+	}
+}
+
+func forcePrimary(ctx *fasthttp.RequestCtx) bool {
+	value := ctx.Request.Header.Peek(dbrouter.Header)
+	if len(value) == 0 {
+		return false
+	}
+	force, err := strconv.ParseBool(string(value))
+	// an unparsable value still asks for fresh data
+	return err != nil || force
+}
@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"strings"
+
+	json "github.com/bytedance/sonic"
+	"github.com/valyala/fasthttp"
+	"go.uber.org/zap"
+
+	"github.com/viewsharp/technopark-forum/internal/handlers"
+	"github.com/viewsharp/technopark-forum/internal/metrics"
+	"github.com/viewsharp/technopark-forum/internal/requestid"
+)
+
+// Panics counts recovered panics by the function and line that raised them.
+var Panics = metrics.Register(metrics.NewCounterVec(
+	"http_panics_total",
+	"Number of panics recovered while handling requests.",
+	"site",
+))
+
+// Recovery turns a panic in next into a 500 handlers.Error problem and logs
+// it with the stack and request URI.
+func Recovery(logger *zap.Logger) Middleware {
+	return func(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+		return func(ctx *fasthttp.RequestCtx) {
+			defer func() {
+				recovered := recover()
+				if recovered == nil {
+					return
+				}
+
+				site := panicSite()
+				Panics.With(site).Inc()
+				logger.Error(
+					"panic",
+					zap.String("panic", fmt.Sprint(recovered)),
+					zap.String("site", site),
+					zap.ByteString("method", ctx.Method()),
+					zap.ByteString("uri", ctx.Request.Header.RequestURI()),
+					zap.String("request_id", requestid.FromContext(ctx)),
+					zap.ByteString("stack", debug.Stack()),
+				)
+
+				problem := handlers.NewError(handlers.ErrInternal, "Internal server error", "")
+				body, _ := json.Marshal(problem)
+				ctx.Response.Reset()
+				ctx.Response.Header.Set("Content-Type", problem.ContentType())
+				ctx.SetStatusCode(problem.Status)
+				ctx.SetBody(body)
+			}()
+
+			next(ctx)
+		}
+	}
+}
+
+// panicSite returns the first frame outside the runtime below the panic, that
+// is the code which actually panicked.
+func panicSite() string {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(3, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	inRuntime := false
+	for {
+		frame, more := frames.Next()
+		if strings.HasPrefix(frame.Function, "runtime.") {
+			inRuntime = true
+		} else if inRuntime {
+			return fmt.Sprintf("%s:%d", frame.Function, frame.Line)
+		}
+		if !more {
+			return "unknown"
+		}
+	}
+}
@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"github.com/valyala/fasthttp"
+
+	"github.com/viewsharp/technopark-forum/internal/requestid"
+)
+
+// RequestID takes the request ID from the X-Request-ID header, or generates
+// one, stores it in the request context and echoes it in the response.
+func RequestID(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+	return func(ctx *fasthttp.RequestCtx) {
+		id := string(ctx.Request.Header.Peek(requestid.Header))
+		if !requestid.Valid(id) {
+			id = requestid.New()
+		}
+		requestid.Set(ctx, id)
+
+		next(ctx)
+
+		// set after next so that a response reset by Recovery keeps it
+		ctx.Response.Header.Set(requestid.Header, id)
+	}
+}
@@ -0,0 +1,195 @@
+package openapi
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+type Document struct {
+	OpenAPI    string               `json:"openapi"`
+	Info       Info                 `json:"info"`
+	Paths      map[string]*PathItem `json:"paths"`
+	Components Components           `json:"components"`
+}
+
+type Info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type PathItem struct {
+	Get  *OperationObject `json:"get,omitempty"`
+	Post *OperationObject `json:"post,omitempty"`
+}
+
+type OperationObject struct {
+	Summary     string                     `json:"summary,omitempty"`
+	OperationID string                     `json:"operationId"`
+	Parameters  []ParameterObject          `json:"parameters,omitempty"`
+	RequestBody *RequestBodyObject         `json:"requestBody,omitempty"`
+	Responses   map[string]*ResponseObject `json:"responses"`
+}
+
+type ParameterObject struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type RequestBodyObject struct {
+	Required bool                  `json:"required"`
+	Content  map[string]MediaTypes `json:"content"`
+}
+
+type ResponseObject struct {
+	Description string                   `json:"description"`
+	Headers     map[string]*HeaderObject `json:"headers,omitempty"`
+	Content     map[string]MediaTypes    `json:"content,omitempty"`
+}
+
+type HeaderObject struct {
+	Description string  `json:"description,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type MediaTypes struct {
+	Schema *Schema `json:"schema"`
+}
+
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas"`
+}
+
+// Generate builds the document for ops. Error statuses listed by an
+// operation are documented with the schema of errorBody served as
+// errorContentType. It panics if an operation fails validate or if two
+// distinct structs share a name, as both would be emitted under one
+// component.
+func Generate(info Info, ops []*Operation, errorBody any, errorContentType string) *Document {
+	schemas := newSchemaSet()
+	doc := &Document{
+		OpenAPI:    "3.0.3",
+		Info:       info,
+		Paths:      make(map[string]*PathItem),
+		Components: Components{Schemas: schemas.components},
+	}
+
+	for _, op := range ops {
+		if err := op.validate(); err != nil {
+			panic("openapi: " + err.Error())
+		}
+
+		path := templatePath(op.Path)
+		item, ok := doc.Paths[path]
+		if !ok {
+			item = &PathItem{}
+			doc.Paths[path] = item
+		}
+
+		object := &OperationObject{
+			Summary:     op.Summary,
+			OperationID: operationID(op),
+			Parameters:  parameters(op),
+			Responses:   make(map[string]*ResponseObject),
+		}
+
+		if op.Body != nil {
+			object.RequestBody = &RequestBodyObject{
+				Required: true,
+				Content:  map[string]MediaTypes{"application/json": {Schema: schemas.of(op.Body)}},
+			}
+		}
+
+		for _, response := range op.Responses {
+			responseObject := &ResponseObject{Description: response.Description}
+			switch {
+			case response.ContentType == "application/json":
+				responseObject.Content = map[string]MediaTypes{response.ContentType: {Schema: &Schema{Type: "object"}}}
+			case response.ContentType != "":
+				responseObject.Content = map[string]MediaTypes{response.ContentType: {Schema: &Schema{Type: "string"}}}
+			case response.Body != nil:
+				responseObject.Content = map[string]MediaTypes{"application/json": {Schema: schemas.of(response.Body)}}
+			}
+			for _, header := range response.Headers {
+				if responseObject.Headers == nil {
+					responseObject.Headers = make(map[string]*HeaderObject)
+				}
+				responseObject.Headers[header.Name] = &HeaderObject{
+					Description: header.Description,
+					Schema:      &Schema{Type: "string"},
+				}
+			}
+			object.Responses[strconv.Itoa(response.Status)] = responseObject
+		}
+		if len(object.Responses) == 0 {
+			object.Responses["200"] = &ResponseObject{Description: http.StatusText(http.StatusOK)}
+		}
+
+		for _, status := range op.Errors {
+			object.Responses[strconv.Itoa(status)] = &ResponseObject{
+				Description: http.StatusText(status),
+				Content:     map[string]MediaTypes{errorContentType: {Schema: schemas.of(errorBody)}},
+			}
+		}
+
+		switch op.Method {
+		case http.MethodGet:
+			item.Get = object
+		case http.MethodPost:
+			item.Post = object
+		}
+	}
+
+	return doc
+}
+
+func parameters(op *Operation) []ParameterObject {
+	var result []ParameterObject
+
+	for _, name := range pathParams(op.Path) {
+		param := Param{Name: name, In: "path", Type: "string", Required: true}
+		if i := slices.IndexFunc(op.Params, func(p Param) bool { return p.In == "path" && p.Name == name }); i >= 0 {
+			param = op.Params[i]
+		}
+		result = append(result, parameter(param))
+	}
+
+	for _, param := range op.Params {
+		if param.In != "path" {
+			result = append(result, parameter(param))
+		}
+	}
+
+	return result
+}
+
+func parameter(param Param) ParameterObject {
+	schema := &Schema{Type: param.Type}
+	for _, value := range param.Enum {
+		schema.Enum = append(schema.Enum, value)
+	}
+	return ParameterObject{
+		Name:        param.Name,
+		In:          param.In,
+		Description: param.Description,
+		Required:    param.Required,
+		Schema:      schema,
+	}
+}
+
+// operationID derives a stable id such as getApiThreadSlugOrIdPosts.
+func operationID(op *Operation) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(op.Method))
+	for _, part := range strings.FieldsFunc(op.Path, func(r rune) bool {
+		return r == '/' || r == ':' || r == '*' || r == '_' || r == '-'
+	}) {
+		b.WriteString(strings.ToUpper(part[:1]))
+		b.WriteString(part[1:])
+	}
+	return b.String()
+}
@@ -0,0 +1,157 @@
+package openapi
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Operation describes one registered route. The router creates it on
+// registration and handlers' documentation is attached with the chainable
+// methods below. Query parameters and failures are declared by hand next to
+// the route, apart from the handler that reads and answers them, so they
+// have to be kept in line with it when either changes; validate only
+// catches declarations that contradict themselves.
+type Operation struct {
+	Method    string
+	Path      string
+	Summary   string
+	Params    []Param
+	Body      any
+	Responses []Response
+	Errors    []int
+}
+
+type Param struct {
+	Name        string
+	In          string
+	Type        string
+	Description string
+	Enum        []string
+	Required    bool
+}
+
+type Response struct {
+	Status      int
+	Description string
+	ContentType string
+	Body        any
+	Headers     []Header
+}
+
+type Header struct {
+	Name        string
+	Description string
+}
+
+func NewOperation(method, path string) *Operation {
+	return &Operation{Method: method, Path: path}
+}
+
+func (o *Operation) Describe(summary string) *Operation {
+	o.Summary = summary
+	return o
+}
+
+// DocPath overrides the documented path, for routes that are registered
+// under a wildcard but only answer one concrete path.
+func (o *Operation) DocPath(path string) *Operation {
+	o.Path = path
+	return o
+}
+
+// PathParam sets the type of a path parameter, string by default.
+func (o *Operation) PathParam(name, typ, description string) *Operation {
+	o.Params = append(o.Params, Param{Name: name, In: "path", Type: typ, Description: description, Required: true})
+	return o
+}
+
+func (o *Operation) Query(name, typ, description string, enum ...string) *Operation {
+	o.Params = append(o.Params, Param{Name: name, In: "query", Type: typ, Description: description, Enum: enum})
+	return o
+}
+
+// Accepts documents the JSON request body by an example value of its type.
+func (o *Operation) Accepts(body any) *Operation {
+	o.Body = body
+	return o
+}
+
+// Returns documents a successful response by an example value of its type.
+// A nil body documents a response without content.
+func (o *Operation) Returns(status int, description string, body any) *Operation {
+	o.Responses = append(o.Responses, Response{Status: status, Description: description, Body: body})
+	return o
+}
+
+// ReturnsRaw documents a non JSON response.
+func (o *Operation) ReturnsRaw(status int, description, contentType string) *Operation {
+	o.Responses = append(o.Responses, Response{Status: status, Description: description, ContentType: contentType})
+	return o
+}
+
+// WithHeader documents a string header sent with the last documented
+// response.
+func (o *Operation) WithHeader(name, description string) *Operation {
+	if len(o.Responses) > 0 {
+		last := &o.Responses[len(o.Responses)-1]
+		last.Headers = append(last.Headers, Header{Name: name, Description: description})
+	}
+	return o
+}
+
+// Fails documents error statuses answered with the problem body.
+func (o *Operation) Fails(statuses ...int) *Operation {
+	o.Errors = append(o.Errors, statuses...)
+	return o
+}
+
+// validate reports declarations that can't be documented as given: path
+// parameters missing from the path, parameters declared twice and failures
+// that are not error statuses or are also documented as responses.
+func (o *Operation) validate() error {
+	seen := make(map[string]bool)
+	for _, param := range o.Params {
+		key := param.In + " " + param.Name
+		if seen[key] {
+			return fmt.Errorf("%s %s: %s parameter %s declared twice", o.Method, o.Path, param.In, param.Name)
+		}
+		seen[key] = true
+		if param.In == "path" && !slices.Contains(pathParams(o.Path), param.Name) {
+			return fmt.Errorf("%s %s: path parameter %s is not in the path", o.Method, o.Path, param.Name)
+		}
+	}
+	for _, status := range o.Errors {
+		if status < 400 || status > 599 {
+			return fmt.Errorf("%s %s: failure status %d is not an error", o.Method, o.Path, status)
+		}
+		if slices.ContainsFunc(o.Responses, func(r Response) bool { return r.Status == status }) {
+			return fmt.Errorf("%s %s: status %d documented both as a response and as a failure", o.Method, o.Path, status)
+		}
+	}
+	return nil
+}
+
+// pathParams returns the names of :name and *name segments of a
+// fasthttprouter path.
+func pathParams(path string) []string {
+	var names []string
+	for _, segment := range strings.Split(path, "/") {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			names = append(names, segment[1:])
+		}
+	}
+	return names
+}
+
+// templatePath converts /api/thread/:slug_or_id/posts to
+// /api/thread/{slug_or_id}/posts.
+func templatePath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			segments[i] = "{" + segment[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
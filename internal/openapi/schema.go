@@ -0,0 +1,132 @@
+package openapi
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Schema struct {
+	Ref        string             `json:"$ref,omitempty"`
+	Type       string             `json:"type,omitempty"`
+	Format     string             `json:"format,omitempty"`
+	Items      *Schema            `json:"items,omitempty"`
+	Properties map[string]*Schema `json:"properties,omitempty"`
+	Required   []string           `json:"required,omitempty"`
+	Enum       []any              `json:"enum,omitempty"`
+	Pattern    string             `json:"pattern,omitempty"`
+	MaxLength  *int               `json:"maxLength,omitempty"`
+}
+
+// slugPattern mirrors the slug rule of internal/validation.
+const slugPattern = `^[\w-]*[A-Za-z_-][\w-]*$`
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schemaSet derives schemas from Go types by their json and validate tags.
+// Named structs are emitted once under components and referenced.
+type schemaSet struct {
+	components map[string]*Schema
+	// types records the struct behind each component, so two structs with
+	// the same name in different packages are not merged into one.
+	types map[string]reflect.Type
+}
+
+func newSchemaSet() *schemaSet {
+	return &schemaSet{
+		components: make(map[string]*Schema),
+		types:      make(map[string]reflect.Type),
+	}
+}
+
+func (s *schemaSet) of(value any) *Schema {
+	return s.ofType(reflect.TypeOf(value))
+}
+
+func (s *schemaSet) ofType(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t.Kind() == reflect.Struct:
+		return s.ofStruct(t)
+	}
+
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: s.ofType(t.Elem())}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int32, reflect.Uint32, reflect.Int16, reflect.Uint16, reflect.Int8, reflect.Uint8:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	}
+	return &Schema{}
+}
+
+func (s *schemaSet) ofStruct(t reflect.Type) *Schema {
+	ref := &Schema{Ref: "#/components/schemas/" + t.Name()}
+	if known, ok := s.types[t.Name()]; ok {
+		if known != t {
+			panic(fmt.Sprintf("openapi: %s and %s both map to component %s", known, t, t.Name()))
+		}
+		return ref
+	}
+
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	// registered before the fields so self references terminate
+	s.components[t.Name()] = schema
+	s.types[t.Name()] = t
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		property := s.ofType(field.Type)
+		for _, rule := range strings.Split(field.Tag.Get("validate"), ",") {
+			rule, arg, _ := strings.Cut(rule, "=")
+			switch rule {
+			case "required":
+				schema.Required = append(schema.Required, name)
+			case "email":
+				property.Format = "email"
+			case "slug":
+				property.Pattern = slugPattern
+			case "max":
+				if n, err := strconv.Atoi(arg); err == nil {
+					property.MaxLength = &n
+				}
+			case "oneof":
+				for _, option := range strings.Fields(arg) {
+					if n, err := strconv.Atoi(option); err == nil {
+						property.Enum = append(property.Enum, n)
+					}
+				}
+			}
+		}
+		schema.Properties[name] = property
+	}
+
+	return ref
+}
@@ -7,22 +7,26 @@ import (
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 	"go.uber.org/zap"
-)
 
-var logger = zap.Must(zap.NewProduction())
+	"github.com/viewsharp/technopark-forum/internal/requestid"
+)
 
 type DB interface {
 	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
 	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
 	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults
+	CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error)
+	Begin(ctx context.Context) (pgx.Tx, error)
 }
 
 type QueryLogger struct {
-	db DB
+	db     DB
+	logger *zap.Logger
 }
 
-func NewQueryLogger(db DB) *QueryLogger {
-	return &QueryLogger{db: db}
+func NewQueryLogger(db DB, logger *zap.Logger) *QueryLogger {
+	return &QueryLogger{db: db, logger: logger}
 }
 
 func (q *QueryLogger) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
@@ -30,9 +34,10 @@ func (q *QueryLogger) QueryRow(ctx context.Context, sql string, args ...any) pgx
 
 	row := q.db.QueryRow(ctx, sql, args...)
 
-	logger.Info("query row",
+	q.logger.Info("query row",
 		zap.Duration("duration", time.Since(startedAt)),
 		zap.String("query", sql),
+		zap.String("request_id", requestid.FromContext(ctx)),
 		//zap.Any("args", args),
 	)
 
@@ -44,9 +49,10 @@ func (q *QueryLogger) Query(ctx context.Context, sql string, args ...any) (pgx.R
 
 	rows, err := q.db.Query(ctx, sql, args...)
 
-	logger.Info("query",
+	q.logger.Info("query",
 		zap.Duration("duration", time.Since(startedAt)),
 		zap.String("query", sql),
+		zap.String("request_id", requestid.FromContext(ctx)),
 		//zap.Any("args", args),
 	)
 
@@ -58,11 +64,93 @@ func (q *QueryLogger) Exec(ctx context.Context, sql string, arguments ...any) (p
 
 	result, err := q.db.Exec(ctx, sql, arguments...)
 
-	logger.Info("exec",
+	q.logger.Info("exec",
 		zap.Duration("duration", time.Since(startedAt)),
 		zap.String("query", sql),
+		zap.String("request_id", requestid.FromContext(ctx)),
 		//zap.Any("args", arguments),
 	)
 
 	return result, err
 }
+
+func (q *QueryLogger) SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults {
+	startedAt := time.Now()
+
+	results := q.db.SendBatch(ctx, b)
+
+	var query string
+	if b.Len() > 0 {
+		query = b.QueuedQueries[0].SQL
+	}
+	q.logger.Info("batch",
+		zap.Duration("duration", time.Since(startedAt)),
+		zap.String("query", query),
+		zap.Int("size", b.Len()),
+		zap.String("request_id", requestid.FromContext(ctx)),
+	)
+
+	return results
+}
+
+func (q *QueryLogger) CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error) {
+	startedAt := time.Now()
+
+	rows, err := q.db.CopyFrom(ctx, tableName, columnNames, rowSrc)
+
+	q.logger.Info("copy",
+		zap.Duration("duration", time.Since(startedAt)),
+		zap.String("table", tableName.Sanitize()),
+		zap.Int64("rows", rows),
+		zap.String("request_id", requestid.FromContext(ctx)),
+	)
+
+	return rows, err
+}
+
+// Begin starts a transaction whose statements are logged as well.
+func (q *QueryLogger) Begin(ctx context.Context) (pgx.Tx, error) {
+	startedAt := time.Now()
+
+	tx, err := q.db.Begin(ctx)
+
+	q.logger.Info("begin",
+		zap.Duration("duration", time.Since(startedAt)),
+		zap.String("request_id", requestid.FromContext(ctx)),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &loggedTx{Tx: tx, queries: NewQueryLogger(tx, q.logger)}, nil
+}
+
+// loggedTx routes the statements of a transaction through a QueryLogger.
+type loggedTx struct {
+	pgx.Tx
+	queries *QueryLogger
+}
+
+func (t *loggedTx) Begin(ctx context.Context) (pgx.Tx, error) {
+	return t.queries.Begin(ctx)
+}
+
+func (t *loggedTx) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	return t.queries.QueryRow(ctx, sql, args...)
+}
+
+func (t *loggedTx) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	return t.queries.Query(ctx, sql, args...)
+}
+
+func (t *loggedTx) Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error) {
+	return t.queries.Exec(ctx, sql, arguments...)
+}
+
+func (t *loggedTx) SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults {
+	return t.queries.SendBatch(ctx, b)
+}
+
+func (t *loggedTx) CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error) {
+	return t.queries.CopyFrom(ctx, tableName, columnNames, rowSrc)
+}
@@ -0,0 +1,51 @@
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// Header carries the request ID in both directions.
+const Header = "X-Request-ID"
+
+// key is the fasthttp user value under which the ID is stored. It has to be
+// a string: fasthttp.RequestCtx.Value only resolves string keys.
+const key = "request_id"
+
+const maxLength = 128
+
+type userValueSetter interface {
+	SetUserValue(key string, value interface{})
+}
+
+// Set stores id in the request context.
+func Set(ctx userValueSetter, id string) {
+	ctx.SetUserValue(key, id)
+}
+
+// FromContext returns the request ID of ctx or an empty string. It works with
+// *fasthttp.RequestCtx and every context derived from it.
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(key).(string)
+	return id
+}
+
+// Valid reports whether an ID received from a client is safe to log and echo.
+func Valid(id string) bool {
+	if id == "" || len(id) > maxLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < '!' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+func New() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
@@ -0,0 +1,83 @@
+package router
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"time"
+
+	"github.com/valyala/fasthttp"
+)
+
+// versioner is implemented by response values that identify their content
+// without being encoded, such as forums whose only mutable fields are
+// counters. Other values get an ETag hashed from the encoded body.
+type versioner interface {
+	Version() string
+}
+
+// lastModifier is implemented by response values that know when they last
+// changed. Posts and threads don't: they are edited and voted on after
+// their creation time, so they are revalidated by ETag alone.
+type lastModifier interface {
+	LastModified() time.Time
+}
+
+// entityTag builds a strong ETag over seed. The representation also depends
+// on the negotiated media type and content coding, so both are part of the
+// tag.
+func entityTag(seed []byte, contentType, encoding string) string {
+	hash := sha256.New()
+	hash.Write([]byte(contentType))
+	hash.Write([]byte{0})
+	hash.Write(seed)
+	sum := hash.Sum(nil)
+
+	tag := base64.RawURLEncoding.EncodeToString(sum[:12])
+	if encoding != "" {
+		tag += "-" + encoding
+	}
+	return `"` + tag + `"`
+}
+
+// ifNoneMatch reports whether the If-None-Match header matches etag, using
+// the weak comparison RFC 9110 requires for this header.
+func ifNoneMatch(ctx *fasthttp.RequestCtx, etag string) bool {
+	header := ctx.Request.Header.Peek("If-None-Match")
+	if len(header) == 0 {
+		return false
+	}
+
+	for _, candidate := range bytes.Split(header, []byte(",")) {
+		candidate = bytes.TrimSpace(candidate)
+		if string(candidate) == "*" {
+			return true
+		}
+		candidate = bytes.TrimPrefix(candidate, []byte("W/"))
+		if string(candidate) == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// notModified sets the ETag and, when responseData knows its modification
+// time, Last-Modified validators. no-cache makes clients revalidate on every
+// use. It answers 304 and reports true when the client already holds the
+// representation.
+func notModified(ctx *fasthttp.RequestCtx, responseData interface{}, etag string) bool {
+	ctx.Response.Header.Set("ETag", etag)
+	ctx.Response.Header.Set("Cache-Control", "no-cache")
+	if modifier, ok := responseData.(lastModifier); ok {
+		if lastModified := modifier.LastModified(); !lastModified.IsZero() {
+			ctx.Response.Header.SetLastModified(lastModified)
+		}
+	}
+
+	if !ifNoneMatch(ctx, etag) {
+		return false
+	}
+
+	ctx.SetStatusCode(fasthttp.StatusNotModified)
+	return true
+}
@@ -0,0 +1,223 @@
+package router
+
+import (
+	"bytes"
+	"sort"
+	"strconv"
+	"strings"
+
+	json "github.com/bytedance/sonic"
+	"github.com/fxamacker/cbor/v2"
+	"github.com/valyala/fasthttp"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// codec encodes response values into one media type.
+type codec struct {
+	contentType string
+	aliases     []string
+	marshal     func(v interface{}) ([]byte, error)
+}
+
+var jsonCodec = codec{
+	contentType: "application/json",
+	marshal:     json.Marshal,
+}
+
+// codecs lists the supported response encodings. JSON comes first so it wins
+// over the others when the client accepts any of them with the same quality.
+var codecs = []codec{
+	jsonCodec,
+	{
+		contentType: "application/msgpack",
+		aliases:     []string{"application/x-msgpack", "application/vnd.msgpack"},
+		marshal:     marshalMsgpack,
+	},
+	{
+		contentType: "application/cbor",
+		marshal:     marshalCBOR,
+	},
+}
+
+// marshalMsgpack encodes struct fields under their json names, so every
+// encoding of a response exposes the same keys.
+func marshalMsgpack(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := msgpack.NewEncoder(&buf)
+	enc.SetCustomStructTag("json")
+	enc.UseCompactInts(true)
+	err := enc.Encode(v)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+var cborEncMode = func() cbor.EncMode {
+	mode, err := cbor.EncOptions{Time: cbor.TimeRFC3339Nano}.EncMode()
+	if err != nil {
+		panic(err)
+	}
+	return mode
+}()
+
+// marshalCBOR encodes timestamps as RFC 3339 strings, as in JSON. Struct
+// fields without a cbor tag already fall back to their json names.
+func marshalCBOR(v interface{}) ([]byte, error) {
+	return cborEncMode.Marshal(v)
+}
+
+func (c codec) matches(mediaType string) bool {
+	if mediaType == c.contentType {
+		return true
+	}
+	for _, alias := range c.aliases {
+		if mediaType == alias {
+			return true
+		}
+	}
+	return false
+}
+
+// negotiateCodec picks the codec preferred by the Accept header. A wildcard
+// stands for the media types the header does not list, so it never selects
+// one rejected with q=0. Clients that accept none of the supported media
+// types still get JSON.
+func negotiateCodec(accept []byte) codec {
+	items := parseQualityList(string(accept))
+	for _, item := range items {
+		if item.q <= 0 {
+			break
+		}
+		switch item.value {
+		case "*/*", "application/*":
+			for _, c := range codecs {
+				if !c.listed(items) {
+					return c
+				}
+			}
+			continue
+		}
+		for _, c := range codecs {
+			if c.matches(item.value) {
+				return c
+			}
+		}
+	}
+	return jsonCodec
+}
+
+// listed reports whether items name the codec's media type or an alias.
+func (c codec) listed(items []qualityItem) bool {
+	for _, item := range items {
+		if c.matches(item.value) {
+			return true
+		}
+	}
+	return false
+}
+
+type compressor struct {
+	encoding string
+	append   func(dst, src []byte) []byte
+}
+
+var compressors = []compressor{
+	{
+		encoding: "br",
+		append: func(dst, src []byte) []byte {
+			return fasthttp.AppendBrotliBytesLevel(dst, src, fasthttp.CompressBrotliDefaultCompression)
+		},
+	},
+	{
+		encoding: "gzip",
+		append: func(dst, src []byte) []byte {
+			return fasthttp.AppendGzipBytesLevel(dst, src, fasthttp.CompressDefaultCompression)
+		},
+	},
+}
+
+// negotiateCompressor picks the compressor preferred by the Accept-Encoding
+// header, brotli winning ties. As in negotiateCodec, "*" only selects an
+// encoding the header does not list. It reports false for identity.
+func negotiateCompressor(acceptEncoding []byte) (compressor, bool) {
+	items := parseQualityList(string(acceptEncoding))
+	for _, item := range items {
+		if item.q <= 0 {
+			break
+		}
+		if item.value == "*" {
+			for _, c := range compressors {
+				if !listed(items, c.encoding) {
+					return c, true
+				}
+			}
+			continue
+		}
+		for _, c := range compressors {
+			if item.value == c.encoding {
+				return c, true
+			}
+		}
+	}
+	return compressor{}, false
+}
+
+type qualityItem struct {
+	value string
+	q     float64
+}
+
+// parseQualityList parses Accept style headers, ordering the values by
+// descending quality. Rejected (q=0) values are kept at the end, so callers
+// can tell them from values that are not listed at all.
+func parseQualityList(header string) []qualityItem {
+	var items []qualityItem
+	for _, part := range strings.Split(header, ",") {
+		value, params, _ := strings.Cut(part, ";")
+		value = strings.ToLower(strings.TrimSpace(value))
+		if value == "" {
+			continue
+		}
+
+		q := 1.0
+		for _, param := range strings.Split(params, ";") {
+			name, raw, ok := strings.Cut(strings.TrimSpace(param), "=")
+			if !ok || strings.TrimSpace(name) != "q" {
+				continue
+			}
+			parsed, err := strconv.ParseFloat(strings.TrimSpace(raw), 64)
+			if err == nil {
+				q = max(parsed, 0)
+			}
+		}
+
+		items = append(items, qualityItem{value: value, q: q})
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].q > items[j].q
+	})
+	return items
+}
+
+// listed reports whether value is named in items, whatever its quality.
+func listed(items []qualityItem, value string) bool {
+	for _, item := range items {
+		if item.value == value {
+			return true
+		}
+	}
+	return false
+}
+
+// writeBody sets the response body, compressed with c when compress is set.
+func writeBody(ctx *fasthttp.RequestCtx, body []byte, c compressor, compress bool) {
+	if !compress {
+		ctx.SetBody(body)
+		return
+	}
+
+	ctx.Response.Header.Set("Content-Encoding", c.encoding)
+	ctx.SetBody(c.append(nil, body))
+}
@@ -5,12 +5,34 @@ import (
 	json "github.com/bytedance/sonic"
 	"github.com/valyala/fasthttp"
 
+	"github.com/viewsharp/technopark-forum/internal/config"
+	"github.com/viewsharp/technopark-forum/internal/cursor"
 	"github.com/viewsharp/technopark-forum/internal/handlers"
+	"github.com/viewsharp/technopark-forum/internal/metrics"
+	"github.com/viewsharp/technopark-forum/internal/middleware"
+	"github.com/viewsharp/technopark-forum/internal/openapi"
+	"github.com/viewsharp/technopark-forum/internal/usecase/check"
+	"github.com/viewsharp/technopark-forum/internal/usecase/forum"
+	"github.com/viewsharp/technopark-forum/internal/usecase/post"
+	"github.com/viewsharp/technopark-forum/internal/usecase/status"
+	"github.com/viewsharp/technopark-forum/internal/usecase/thread"
+	"github.com/viewsharp/technopark-forum/internal/usecase/user"
+	"github.com/viewsharp/technopark-forum/internal/usecase/vote"
 )
 
 type HandleFunc func(ctx *fasthttp.RequestCtx) (interface{}, int)
 
-func GetHandler(handleFunc HandleFunc) fasthttp.RequestHandler {
+// contentTyper is implemented by response values served with their own
+// media type, such as handlers.Error. They are always encoded as JSON.
+type contentTyper interface {
+	ContentType() string
+}
+
+// GetHandler adapts handleFunc to fasthttp, encoding the response in the
+// media type negotiated from the Accept header and compressing bodies of at
+// least cfg.Server.CompressMinSize bytes. Successful GET responses carry an
+// ETag and are answered with 304 when If-None-Match matches it.
+func GetHandler(handleFunc HandleFunc, cfg *config.Config) fasthttp.RequestHandler {
 	return func(ctx *fasthttp.RequestCtx) {
 		responseData, statusCode := handleFunc(ctx)
 
@@ -19,66 +41,284 @@ func GetHandler(handleFunc HandleFunc) fasthttp.RequestHandler {
 			return
 		}
 
-		body, err := json.Marshal(&responseData)
+		responseCodec, contentType := jsonCodec, jsonCodec.contentType
+		if typed, ok := responseData.(contentTyper); ok {
+			contentType = typed.ContentType()
+		} else {
+			responseCodec = negotiateCodec(ctx.Request.Header.Peek("Accept"))
+			contentType = responseCodec.contentType
+		}
+		ctx.Response.Header.Add("Vary", "Accept")
+		ctx.Response.Header.Add("Vary", "Accept-Encoding")
+
+		compression, canCompress := negotiateCompressor(ctx.Request.Header.Peek("Accept-Encoding"))
+		canCompress = canCompress && cfg.Server.CompressMinSize > 0
+
+		// The tag names the negotiated coding even for bodies too small to
+		// be compressed: the body size is fixed by the content, so one tag
+		// still maps to one representation.
+		var coding string
+		if canCompress {
+			coding = compression.encoding
+		}
+
+		conditional := ctx.IsGet() && statusCode == fasthttp.StatusOK
+
+		var etag string
+		if v, ok := responseData.(versioner); ok && conditional {
+			etag = entityTag([]byte(v.Version()), contentType, coding)
+			if notModified(ctx, responseData, etag) {
+				return
+			}
+		}
+
+		body, err := responseCodec.marshal(responseData)
 		if err != nil {
-			ctx.SetStatusCode(fasthttp.StatusInternalServerError)
-			ctx.SetBody([]byte(err.Error()))
+			problem := handlers.NewError(handlers.ErrInternal, err.Error(), "")
+			body, _ = json.Marshal(problem)
+			ctx.Response.Header.Set("Content-Type", problem.ContentType())
+			ctx.SetStatusCode(problem.Status)
+			ctx.SetBody(body)
 			return
 		}
 
-		ctx.Response.Header.Set("Content-Type", "application/json")
+		if conditional && etag == "" {
+			etag = entityTag(body, contentType, coding)
+			if notModified(ctx, responseData, etag) {
+				return
+			}
+		}
+
+		ctx.Response.Header.Set("Content-Type", contentType)
 		ctx.SetStatusCode(statusCode)
-		ctx.SetBody(body)
+		writeBody(ctx, body, compression, canCompress && len(body) >= cfg.Server.CompressMinSize)
 	}
 }
 
 type Router struct {
 	*fasthttprouter.Router
-}
+	cfg *config.Config
+
+	middlewares []middleware.Middleware
+	handler     fasthttp.RequestHandler
 
-func (r *Router) POST(path string, handle HandleFunc) {
-	r.Handle("POST", path, GetHandler(handle))
+	operations []*openapi.Operation
+	openAPI    []byte
 }
 
-func (r *Router) GET(path string, handle HandleFunc) {
-	r.Handle("GET", path, GetHandler(handle))
+// Use appends middlewares applied to every request, including the ones that
+// match no route. The first registered middleware is the outermost one.
+func (r *Router) Use(middlewares ...middleware.Middleware) {
+	r.middlewares = append(r.middlewares, middlewares...)
+	r.handler = middleware.Chain(r.Router.Handler, r.middlewares...)
 }
 
-func New(sb *handlers.UsecaseSet) Router {
-	router := Router{fasthttprouter.New()}
+// Handler dispatches the request through the global middlewares to the
+// matched route.
+func (r *Router) Handler(ctx *fasthttp.RequestCtx) {
+	r.handler(ctx)
+}
 
-	router.Handle("GET", "/api", func(ctx *fasthttp.RequestCtx) {
-		ctx.SetBody([]byte("[]"))
+// Handle registers a raw handler wrapped with per-route middlewares. The
+// route template is stored under middleware.RouteKey for the global ones.
+// The returned operation documents the route in the OpenAPI document.
+func (r *Router) Handle(method, path string, handler fasthttp.RequestHandler, middlewares ...middleware.Middleware) *openapi.Operation {
+	handler = middleware.Chain(handler, middlewares...)
+	r.Router.Handle(method, path, func(ctx *fasthttp.RequestCtx) {
+		ctx.SetUserValue(middleware.RouteKey, path)
+		handler(ctx)
 	})
 
+	operation := openapi.NewOperation(method, path)
+	r.operations = append(r.operations, operation)
+	return operation
+}
+
+func (r *Router) POST(path string, handle HandleFunc, middlewares ...middleware.Middleware) *openapi.Operation {
+	return r.Handle("POST", path, GetHandler(handle, r.cfg), middlewares...)
+}
+
+func (r *Router) GET(path string, handle HandleFunc, middlewares ...middleware.Middleware) *openapi.Operation {
+	return r.Handle("GET", path, GetHandler(handle, r.cfg), middlewares...)
+}
+
+func New(sb *handlers.UsecaseSet, cfg *config.Config) *Router {
+	router := &Router{Router: fasthttprouter.New(), cfg: cfg}
+	router.handler = router.Router.Handler
+
+	router.Handle("GET", "/metrics", metrics.Default.Handler).
+		Describe("Prometheus metrics").
+		ReturnsRaw(fasthttp.StatusOK, "Metrics in the text exposition format", "text/plain")
+
+	router.Handle("GET", "/api", router.serveOpenAPI).
+		Describe("This OpenAPI document").
+		ReturnsRaw(fasthttp.StatusOK, "OpenAPI 3 document", "application/json")
+
 	forumHandler := handlers.NewForumHandler(sb)
-	router.POST("/api/forum/:slug", forumHandler.Create) // "/api/forum/create"
-	router.GET("/api/forum/:slug/details", forumHandler.Get)
+	router.POST("/api/forum/:slug", forumHandler.Create).
+		DocPath("/api/forum/create").
+		Describe("Create a forum").
+		Accepts(forum.Forum{}).
+		Returns(fasthttp.StatusCreated, "Forum created", forum.Forum{}).
+		Returns(fasthttp.StatusConflict, "Forum already exists", forum.Forum{}).
+		Fails(fasthttp.StatusBadRequest, fasthttp.StatusNotFound)
+	router.GET("/api/forum/:slug/details", forumHandler.Get).
+		Describe("Get forum details").
+		Returns(fasthttp.StatusOK, "Forum", forum.Forum{}).
+		Fails(fasthttp.StatusNotFound)
 
 	threadHandler := handlers.NewThreadHandler(sb)
-	router.POST("/api/forum/:slug/create", threadHandler.Create)
-	router.GET("/api/forum/:slug/threads", threadHandler.GetByForum)
-	router.GET("/api/thread/:slug_or_id/details", threadHandler.Get)
-	router.POST("/api/thread/:slug_or_id/details", threadHandler.Update)
+	router.POST("/api/forum/:slug/create", threadHandler.Create).
+		Describe("Create a thread in the forum").
+		Accepts(thread.Thread{}).
+		Returns(fasthttp.StatusCreated, "Thread created", thread.Thread{}).
+		Returns(fasthttp.StatusConflict, "Thread with this slug already exists", thread.Thread{}).
+		Fails(fasthttp.StatusBadRequest, fasthttp.StatusNotFound)
+	router.GET("/api/forum/:slug/threads", threadHandler.GetByForum).
+		Describe("List forum threads by creation time").
+		Query("limit", "integer", "Maximum number of threads").
+		Query("since", "string", "RFC 3339 creation time to start from, inclusive").
+		Query("cursor", "string", "X-Next-Cursor of the previous page, instead of since").
+		Query("desc", "boolean", "Sort newest first").
+		Returns(fasthttp.StatusOK, "Threads", thread.Threads{}).
+		WithHeader(cursor.Header, "Cursor of the next page, absent on the last page").
+		Fails(fasthttp.StatusBadRequest, fasthttp.StatusNotFound)
+	router.GET("/api/thread/:slug_or_id/details", threadHandler.Get).
+		Describe("Get thread details").
+		PathParam("slug_or_id", "string", "Thread slug or numeric id").
+		Returns(fasthttp.StatusOK, "Thread", thread.Thread{}).
+		Fails(fasthttp.StatusNotFound)
+	router.POST("/api/thread/:slug_or_id/details", threadHandler.Update).
+		Describe("Update thread title or message").
+		PathParam("slug_or_id", "string", "Thread slug or numeric id").
+		Accepts(thread.ThreadUpdate{}).
+		Returns(fasthttp.StatusOK, "Updated thread", thread.Thread{}).
+		Fails(fasthttp.StatusBadRequest, fasthttp.StatusNotFound)
+	router.GET("/api/thread/:slug_or_id/revisions", threadHandler.Revisions).
+		Describe("List earlier versions of the thread title and message, oldest first").
+		PathParam("slug_or_id", "string", "Thread slug or numeric id").
+		Returns(fasthttp.StatusOK, "Revisions", thread.ThreadRevisions{}).
+		Fails(fasthttp.StatusBadRequest, fasthttp.StatusNotFound)
 
 	userHandler := handlers.NewUserHandler(sb)
-	router.GET("/api/user/:nickname/profile", userHandler.Get)
-	router.POST("/api/user/:nickname/profile", userHandler.Update)
-	router.POST("/api/user/:nickname/create", userHandler.Create)
-	router.GET("/api/forum/:slug/users", userHandler.GetByForum)
+	router.GET("/api/user/:nickname/profile", userHandler.Get).
+		Describe("Get user profile").
+		Returns(fasthttp.StatusOK, "User", user.User{}).
+		Fails(fasthttp.StatusNotFound)
+	router.POST("/api/user/:nickname/profile", userHandler.Update).
+		Describe("Update user profile").
+		Accepts(user.UserUpdate{}).
+		Returns(fasthttp.StatusOK, "Updated user", user.User{}).
+		Fails(fasthttp.StatusBadRequest, fasthttp.StatusNotFound, fasthttp.StatusConflict)
+	router.POST("/api/user/:nickname/create", userHandler.Create).
+		Describe("Create a user").
+		Accepts(user.User{}).
+		Returns(fasthttp.StatusCreated, "User created", user.User{}).
+		Returns(fasthttp.StatusConflict, "Users with the same nickname or email", user.Users{}).
+		Fails(fasthttp.StatusBadRequest)
+	router.GET("/api/forum/:slug/users", userHandler.GetByForum).
+		Describe("List users who posted in the forum by nickname").
+		Query("limit", "integer", "Maximum number of users").
+		Query("since", "string", "Nickname to start after").
+		Query("cursor", "string", "X-Next-Cursor of the previous page, instead of since").
+		Query("desc", "boolean", "Sort in descending order").
+		Returns(fasthttp.StatusOK, "Users", user.Users{}).
+		WithHeader(cursor.Header, "Cursor of the next page, absent on the last page").
+		Fails(fasthttp.StatusBadRequest, fasthttp.StatusNotFound)
 
 	postHandler := handlers.NewPostHandler(sb)
-	router.POST("/api/thread/:slug_or_id/create", postHandler.Create)
-	router.GET("/api/thread/:slug_or_id/posts", postHandler.GetByThread)
-	router.GET("/api/post/:id/details", postHandler.Get)
-	router.POST("/api/post/:id/details", postHandler.Update)
+	router.POST("/api/thread/:slug_or_id/create", postHandler.Create).
+		Describe("Create posts in the thread").
+		PathParam("slug_or_id", "string", "Thread slug or numeric id").
+		Accepts([]post.Post{}).
+		Returns(fasthttp.StatusCreated, "Posts created", []post.Post{}).
+		Fails(fasthttp.StatusBadRequest, fasthttp.StatusNotFound, fasthttp.StatusConflict)
+	router.GET("/api/thread/:slug_or_id/posts", postHandler.GetByThread).
+		Describe("List thread posts").
+		PathParam("slug_or_id", "string", "Thread slug or numeric id").
+		Query("limit", "integer", "Maximum number of posts, of root posts for parent_tree").
+		Query("since", "integer", "Post id to start after").
+		Query("cursor", "string", "X-Next-Cursor of the previous page, with the same sort and desc, instead of since").
+		Query("sort", "string", "Sort mode, top ranks every tree level by votes, flat_top all posts", "flat", "tree", "parent_tree", "top", "flat_top").
+		Query("desc", "boolean", "Sort in descending order").
+		Returns(fasthttp.StatusOK, "Posts", []post.Post{}).
+		WithHeader(cursor.Header, "Cursor of the next page, absent on the last page").
+		Fails(fasthttp.StatusBadRequest, fasthttp.StatusNotFound)
+	router.GET("/api/post/:id/details", postHandler.Get).
+		Describe("Get post details").
+		PathParam("id", "integer", "Post id").
+		Query("related", "string", "Comma separated related objects: user, forum, thread, revisions (the edit count)").
+		Returns(fasthttp.StatusOK, "Post with related objects", post.PostFull{}).
+		Fails(fasthttp.StatusBadRequest, fasthttp.StatusNotFound)
+	router.POST("/api/post/:id/details", postHandler.Update).
+		Describe("Update post message").
+		PathParam("id", "integer", "Post id").
+		Accepts(post.PostUpdate{}).
+		Returns(fasthttp.StatusOK, "Updated post", post.Post{}).
+		Fails(fasthttp.StatusBadRequest, fasthttp.StatusNotFound)
+	router.GET("/api/post/:id/revisions", postHandler.Revisions).
+		Describe("List earlier messages of the post, oldest first").
+		PathParam("id", "integer", "Post id").
+		Returns(fasthttp.StatusOK, "Revisions", post.PostRevisions{}).
+		Fails(fasthttp.StatusBadRequest, fasthttp.StatusNotFound)
 
 	voteHandler := handlers.NewVoteHandler(sb)
-	router.POST("/api/thread/:slug_or_id/vote", voteHandler.Create)
+	router.POST("/api/thread/:slug_or_id/vote", voteHandler.Create).
+		Describe("Vote for the thread, a voice of 0 retracts the vote").
+		PathParam("slug_or_id", "string", "Thread slug or numeric id").
+		Accepts(vote.Vote{}).
+		Returns(fasthttp.StatusOK, "Thread with updated votes", thread.Thread{}).
+		Fails(fasthttp.StatusBadRequest, fasthttp.StatusNotFound)
+	router.POST("/api/post/:id/vote", voteHandler.CreateForPost).
+		Describe("Vote for the post, a voice of 0 retracts the vote").
+		PathParam("id", "integer", "Post id").
+		Accepts(vote.Vote{}).
+		Returns(fasthttp.StatusOK, "Post with updated votes", post.Post{}).
+		Fails(fasthttp.StatusBadRequest, fasthttp.StatusNotFound)
+	router.GET("/api/thread/:slug_or_id/votes", voteHandler.GetByThread).
+		Describe("List thread votes by voter nickname").
+		PathParam("slug_or_id", "string", "Thread slug or numeric id").
+		Query("limit", "integer", "Maximum number of votes").
+		Query("since", "string", "Nickname to start after").
+		Query("cursor", "string", "X-Next-Cursor of the previous page, instead of since").
+		Query("desc", "boolean", "Sort by nickname descending").
+		Query("voice", "integer", "Only votes with this voice, -1 or 1").
+		Returns(fasthttp.StatusOK, "Votes", vote.Votes{}).
+		WithHeader(cursor.Header, "Cursor of the next page, absent on the last page").
+		Fails(fasthttp.StatusBadRequest, fasthttp.StatusNotFound)
+	router.GET("/api/thread/:slug_or_id/vote", voteHandler.Get).
+		Describe("Get a user's vote on the thread, voice 0 if they have not voted").
+		PathParam("slug_or_id", "string", "Thread slug or numeric id").
+		Query("nickname", "string", "Voter nickname").
+		Returns(fasthttp.StatusOK, "Vote", vote.Vote{}).
+		Fails(fasthttp.StatusBadRequest, fasthttp.StatusNotFound)
 
 	serviceHandler := handlers.NewServiceHandler(sb)
-	router.GET("/api/service/status", serviceHandler.Status)
-	router.POST("/api/service/clear", serviceHandler.Clear)
+	router.GET("/api/service/status", serviceHandler.Status).
+		Describe("Get row counts").
+		Returns(fasthttp.StatusOK, "Row counts", status.Status{})
+	router.POST("/api/service/clear", serviceHandler.Clear).
+		Describe("Delete all data").
+		Returns(fasthttp.StatusOK, "Data deleted", nil)
+	router.POST("/api/service/check", serviceHandler.Check).
+		Describe("Check denormalized counters and post paths, without repairing them").
+		Returns(fasthttp.StatusOK, "Drift report", check.Report{})
+
+	// Built once every route is registered, so a document that can't be
+	// generated stops the server at startup.
+	doc := openapi.Generate(
+		openapi.Info{Title: "Technopark forum", Version: "1.0"},
+		router.operations,
+		handlers.Error{},
+		handlers.Error{}.ContentType(),
+	)
+	router.openAPI, _ = json.Marshal(doc)
 
 	return router
 }
+
+// serveOpenAPI serves the document generated from the registered routes.
+func (r *Router) serveOpenAPI(ctx *fasthttp.RequestCtx) {
+	ctx.Response.Header.Set("Content-Type", "application/json")
+	ctx.SetBody(r.openAPI)
+}
@@ -0,0 +1,47 @@
+package txmanager
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/viewsharp/technopark-forum/internal/db"
+)
+
+type Beginner interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+// Manager runs multi-statement writes as a single unit of work.
+type Manager struct {
+	db      Beginner
+	queries *db.Queries
+}
+
+func New(db Beginner, queries *db.Queries) *Manager {
+	return &Manager{db: db, queries: queries}
+}
+
+// Do runs fn with queries bound to a new transaction. The transaction is
+// committed when fn returns nil and rolled back otherwise; fn's error is
+// returned unchanged so callers can still match sentinel errors.
+func (m *Manager) Do(ctx context.Context, fn func(queries *db.Queries) error) error {
+	tx, err := m.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	// a no-op once the transaction is committed
+	defer tx.Rollback(context.WithoutCancel(ctx))
+
+	err = fn(m.queries.WithTx(tx))
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package check
+
+import "github.com/viewsharp/technopark-forum/internal/db"
+
+// Report lists the rows whose denormalized columns differ from the values
+// recomputed from the source tables. Repaired is set when they were
+// rewritten in the same transaction.
+type Report struct {
+	Forums    []ForumDrift    `json:"forums"`
+	Threads   []ThreadDrift   `json:"threads"`
+	Posts     []PostDrift     `json:"posts"`
+	PostVotes []PostVoteDrift `json:"post_votes"`
+	Repaired  bool            `json:"repaired"`
+}
+
+// Empty reports whether nothing drifted.
+func (r *Report) Empty() bool {
+	return len(r.Forums) == 0 && len(r.Threads) == 0 && len(r.Posts) == 0 && len(r.PostVotes) == 0
+}
+
+// ForumDrift compares the posts and threads counters with the actual counts.
+// MissingUsers and ExtraUsers count the forum_user rows the forum lacks or
+// should not have.
+type ForumDrift struct {
+	Slug          string `json:"slug"`
+	Posts         int32  `json:"posts"`
+	ActualPosts   int32  `json:"actual_posts"`
+	Threads       int32  `json:"threads"`
+	ActualThreads int32  `json:"actual_threads"`
+	MissingUsers  int32  `json:"missing_users"`
+	ExtraUsers    int32  `json:"extra_users"`
+}
+
+type ThreadDrift struct {
+	Id          int32  `json:"id"`
+	Forum       string `json:"forum"`
+	Votes       int32  `json:"votes"`
+	ActualVotes int32  `json:"actual_votes"`
+}
+
+// PostDrift compares the stored path, root id and sort key with the ones
+// rebuilt from the parent ids.
+type PostDrift struct {
+	Id              int32   `json:"id"`
+	Thread          int32   `json:"thread"`
+	Path            []int32 `json:"path"`
+	ExpectedPath    []int32 `json:"expected_path"`
+	RootId          int32   `json:"root_id"`
+	ExpectedRootId  int32   `json:"expected_root_id"`
+	SortKey         []int32 `json:"sort_key"`
+	ExpectedSortKey []int32 `json:"expected_sort_key"`
+}
+
+type PostVoteDrift struct {
+	Id          int32 `json:"id"`
+	Thread      int32 `json:"thread"`
+	Votes       int32 `json:"votes"`
+	ActualVotes int32 `json:"actual_votes"`
+}
+
+func forumDriftFromDB(row db.ListForumDriftRow) ForumDrift {
+	return ForumDrift(row)
+}
+
+func threadDriftFromDB(row db.ListThreadVoteDriftRow) ThreadDrift {
+	return ThreadDrift{
+		Id:          row.ID,
+		Forum:       row.ForumSlug,
+		Votes:       row.Votes,
+		ActualVotes: row.ActualVotes,
+	}
+}
+
+func postDriftFromDB(row db.ListPostPathDriftRow) PostDrift {
+	return PostDrift{
+		Id:              row.ID,
+		Thread:          row.ThreadID,
+		Path:            row.Path,
+		ExpectedPath:    row.ExpectedPath,
+		RootId:          row.RootID,
+		ExpectedRootId:  row.ExpectedRootID,
+		SortKey:         row.SortKey,
+		ExpectedSortKey: row.ExpectedSortKey,
+	}
+}
+
+func postVoteDriftFromDB(row db.ListPostVoteDriftRow) PostVoteDrift {
+	return PostVoteDrift{
+		Id:          row.ID,
+		Thread:      row.ThreadID,
+		Votes:       row.Votes,
+		ActualVotes: row.ActualVotes,
+	}
+}
@@ -0,0 +1,109 @@
+package check
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/viewsharp/technopark-forum/internal/db"
+	"github.com/viewsharp/technopark-forum/internal/txmanager"
+)
+
+type Usecase struct {
+	Tx *txmanager.Manager
+}
+
+// Run recomputes the forum, thread and post counters, the forum users and the
+// post paths and reports where they drifted. With repair the source tables are
+// locked against writes, so the drift cannot change between the report and
+// the fix, and the drifted rows are rewritten in the same transaction.
+func (s *Usecase) Run(ctx context.Context, repair bool) (*Report, error) {
+	var report *Report
+	err := s.Tx.Do(ctx, func(queries *db.Queries) error {
+		if repair {
+			err := queries.LockCounterSources(ctx)
+			if err != nil {
+				return fmt.Errorf("lock tables: %w", err)
+			}
+		}
+
+		var err error
+		report, err = collect(ctx, queries)
+		if err != nil {
+			return err
+		}
+		if !repair || report.Empty() {
+			return nil
+		}
+
+		err = fix(ctx, queries)
+		if err != nil {
+			return err
+		}
+		report.Repaired = true
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+func collect(ctx context.Context, queries *db.Queries) (*Report, error) {
+	forums, err := queries.ListForumDrift(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("check forums: %w", err)
+	}
+	threads, err := queries.ListThreadVoteDrift(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("check threads: %w", err)
+	}
+	posts, err := queries.ListPostPathDrift(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("check posts: %w", err)
+	}
+	postVotes, err := queries.ListPostVoteDrift(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("check post votes: %w", err)
+	}
+
+	report := &Report{
+		Forums:    make([]ForumDrift, 0, len(forums)),
+		Threads:   make([]ThreadDrift, 0, len(threads)),
+		Posts:     make([]PostDrift, 0, len(posts)),
+		PostVotes: make([]PostVoteDrift, 0, len(postVotes)),
+	}
+	for _, row := range forums {
+		report.Forums = append(report.Forums, forumDriftFromDB(row))
+	}
+	for _, row := range threads {
+		report.Threads = append(report.Threads, threadDriftFromDB(row))
+	}
+	for _, row := range posts {
+		report.Posts = append(report.Posts, postDriftFromDB(row))
+	}
+	for _, row := range postVotes {
+		report.PostVotes = append(report.PostVotes, postVoteDriftFromDB(row))
+	}
+	return report, nil
+}
+
+func fix(ctx context.Context, queries *db.Queries) error {
+	steps := []struct {
+		name string
+		run  func(context.Context) (int64, error)
+	}{
+		{"repair forum counters", queries.RepairForumCounters},
+		{"insert missing forum users", queries.InsertMissingForumUsers},
+		{"delete extra forum users", queries.DeleteExtraForumUsers},
+		{"repair thread votes", queries.RepairThreadVotes},
+		{"repair post paths", queries.RepairPostPaths},
+		{"repair post votes", queries.RepairPostVotes},
+	}
+	for _, step := range steps {
+		_, err := step.run(ctx)
+		if err != nil {
+			return fmt.Errorf("%s: %w", step.name, err)
+		}
+	}
+	return nil
+}
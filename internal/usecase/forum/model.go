@@ -1,9 +1,43 @@
 package forum
 
+import (
+	"fmt"
+
+	"github.com/viewsharp/technopark-forum/internal/db"
+)
+
 type Forum struct {
 	Posts   *int32  `json:"posts"`
-	Slug    *string `json:"slug"`
+	Slug    *string `json:"slug" validate:"required,slug"`
 	Threads *int32  `json:"threads"`
-	Title   *string `json:"title"`
-	User    *string `json:"user"`
-}
\ No newline at end of file
+	Title   *string `json:"title" validate:"required"`
+	User    *string `json:"user" validate:"required"`
+}
+
+// FromDB converts a forums row.
+func FromDB(f db.Forum) *Forum {
+	return &Forum{
+		Posts:   &f.Posts.Int32,
+		Slug:    &f.Slug,
+		Threads: &f.Threads.Int32,
+		Title:   &f.Title,
+		User:    &f.UserNn,
+	}
+}
+
+// Version identifies the forum content for ETags: its title and owner never
+// change, so the counters tell every revision apart.
+func (f Forum) Version() string {
+	var slug string
+	var posts, threads int32
+	if f.Slug != nil {
+		slug = *f.Slug
+	}
+	if f.Posts != nil {
+		posts = *f.Posts
+	}
+	if f.Threads != nil {
+		threads = *f.Threads
+	}
+	return fmt.Sprintf("%s:%d:%d", slug, posts, threads)
+}
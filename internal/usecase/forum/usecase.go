@@ -8,22 +8,17 @@ import (
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 
+	"github.com/viewsharp/technopark-forum/internal/cache"
 	"github.com/viewsharp/technopark-forum/internal/db"
 )
 
-type DB interface {
-	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
-	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
-	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
-}
-
 type Usecase struct {
-	DB      DB
 	Queries *db.Queries
+	Cache   *cache.Entities
 }
 
 func (s *Usecase) Add(ctx context.Context, forum Forum) (*Forum, error) {
-	user, err := s.Queries.GetUserByNickname(ctx, *forum.User)
+	user, err := s.Cache.UserByNickname(ctx, *forum.User)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, ErrNotFoundUser
@@ -44,45 +39,31 @@ func (s *Usecase) Add(ctx context.Context, forum Forum) (*Forum, error) {
 		return nil, fmt.Errorf("insert forum: %w", err)
 	}
 
-	return &Forum{
-		Posts:   &dbForum.Posts.Int32,
-		Slug:    &dbForum.Slug,
-		Threads: &dbForum.Threads.Int32,
-		Title:   &dbForum.Title,
-		User:    &dbForum.UserNn,
-	}, nil
+	return FromDB(dbForum), nil
 }
 
+// BySlug returns the forum without its counters, which lets it come from
+// the cache. FullBySlug always reads the current counters.
 func (s *Usecase) BySlug(ctx context.Context, slug string) (*Forum, error) {
-	var result Forum
-
-	err := s.DB.QueryRow(ctx, "SELECT slug, title, user_nn FROM forums WHERE slug = $1",
-		slug,
-	).Scan(&result.Slug, &result.Title, &result.User)
+	dbForum, err := s.Cache.ForumBySlug(ctx, slug)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, ErrNotFound
 		}
 		return nil, fmt.Errorf("select forum by slug: %w", err)
 	}
-	return &result, nil
+	result := FromDB(dbForum)
+	result.Posts, result.Threads = nil, nil
+	return result, nil
 }
 
 func (s *Usecase) FullBySlug(ctx context.Context, slug string) (*Forum, error) {
-	var result Forum
-
-	err := s.DB.QueryRow(
-		ctx,
-		"SELECT posts, slug, threads, title, user_nn FROM forums WHERE slug = $1",
-		slug,
-	).Scan(&result.Posts, &result.Slug, &result.Threads, &result.Title, &result.User)
+	result, err := s.Queries.GetForumBySlug(ctx, slug)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, ErrNotFound
 		}
 		return nil, fmt.Errorf("select forum by slug: %w", err)
 	}
-	return &result, nil
+	return FromDB(result), nil
 }
-
-//5148.50
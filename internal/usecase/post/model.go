@@ -3,20 +3,45 @@ package post
 import (
 	"time"
 
+	"github.com/viewsharp/technopark-forum/internal/db"
 	"github.com/viewsharp/technopark-forum/internal/usecase/forum"
 	"github.com/viewsharp/technopark-forum/internal/usecase/thread"
 	"github.com/viewsharp/technopark-forum/internal/usecase/user"
 )
 
 type Post struct {
-	Author   *string    `json:"author"`
+	Author   *string    `json:"author" validate:"required"`
 	Created  *time.Time `json:"created,omitempty"`
 	Forum    *string    `json:"forum,omitempty"`
 	Id       *int32     `json:"id,omitempty"`
 	IsEdited *bool      `json:"isEdited,omitempty"`
-	Message  *string    `json:"message"`
+	Message  *string    `json:"message" validate:"required,max=65536"`
 	Parent   *int32     `json:"parent,omitempty"`
 	Thread   *int32     `json:"thread,omitempty"`
+	Votes    *int32     `json:"votes,omitempty"`
+}
+
+// FromDB converts a posts row. Rows carry no forum, so the caller passes
+// the one of the thread.
+func FromDB(p db.Post, forumSlug string) Post {
+	post := Post{
+		Author:  &p.UserNn,
+		Forum:   &forumSlug,
+		Id:      &p.ID,
+		Message: &p.Message,
+		Thread:  &p.ThreadID,
+		Votes:   &p.Votes,
+	}
+	if p.Created.Valid {
+		post.Created = &p.Created.Time
+	}
+	if p.Isedited.Valid {
+		post.IsEdited = &p.Isedited.Bool
+	}
+	if p.ParentID.Valid {
+		post.Parent = &p.ParentID.Int32
+	}
+	return post
 }
 
 type PostFull struct {
@@ -24,8 +49,69 @@ type PostFull struct {
 	Forum  *forum.Forum   `json:"forum,omitempty"`
 	Post   *Post          `json:"post,omitempty"`
 	Thread *thread.Thread `json:"thread,omitempty"`
+	// Revisions is the number of edits of the post.
+	Revisions *int32 `json:"revisions,omitempty"`
+}
+
+// Sort modes of thread post listings.
+const (
+	SortFlat       = "flat"
+	SortTree       = "tree"
+	SortParentTree = "parent_tree"
+	// SortTop orders posts like SortTree, but ranks the posts of every
+	// level by votes, highest first. Every page walks the whole thread.
+	SortTop = "top"
+	// SortFlatTop orders all posts by votes, highest first, then by id,
+	// without regard to the tree.
+	SortFlatTop = "flat_top"
+)
+
+// Page selects one page of a thread's posts. Since is the id of the post to
+// continue after; After is the cursor handed out with the previous page and
+// saves looking that post up.
+type Page struct {
+	Sort  string
+	Desc  bool
+	Since int
+	After *Cursor
+	Limit int
+}
+
+// Cursor is the position of the last post of a page. Flat pages continue
+// after Created and ID; tree and parent_tree pages after Path, the post's path
+// followed by its id, which is also its sort key. Top and flat_top pages
+// continue after the post's rank key, kept in Path as well.
+type Cursor struct {
+	Sort string  `json:"sort"`
+	Desc bool    `json:"desc"`
+	ID   int32   `json:"id,omitempty"`
+	Path []int32 `json:"path,omitempty"`
+	// Created is set for flat pages only.
+	Created *time.Time `json:"created,omitempty"`
+}
+
+// PostRevision is the message of a post before the edit made at Edited.
+type PostRevision struct {
+	Id      int32     `json:"id"`
+	Message string    `json:"message"`
+	Edited  time.Time `json:"edited"`
+}
+
+func RevisionFromDB(r db.PostRevision) PostRevision {
+	return PostRevision{Id: r.ID, Message: r.Message, Edited: r.Edited.Time}
+}
+
+type PostRevisions []PostRevision
+
+// LastModified returns the time of the latest edit. Revisions are only ever
+// appended, so the list changes exactly when a newer one is added.
+func (r PostRevisions) LastModified() time.Time {
+	if len(r) == 0 {
+		return time.Time{}
+	}
+	return r[len(r)-1].Edited
 }
 
 type PostUpdate struct {
-	Message *string `json:"message,omitempty"`
+	Message *string `json:"message,omitempty" validate:"max=65536"`
 }
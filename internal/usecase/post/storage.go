@@ -7,33 +7,32 @@ import (
 	"maps"
 	"regexp"
 	"slices"
-	"strings"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgtype"
 
+	"github.com/viewsharp/technopark-forum/internal/cache"
 	"github.com/viewsharp/technopark-forum/internal/db"
+	"github.com/viewsharp/technopark-forum/internal/txmanager"
 	"github.com/viewsharp/technopark-forum/internal/usecase/forum"
 	"github.com/viewsharp/technopark-forum/internal/usecase/thread"
 	"github.com/viewsharp/technopark-forum/internal/usecase/user"
 )
 
-type DB interface {
-	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
-	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
-	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
-}
-
 type Usecase struct {
-	DB      DB
 	Queries *db.Queries
+	Cache   *cache.Entities
+	Tx      *txmanager.Manager
+	// CopyThreshold is the number of posts above which a batch is written
+	// with COPY instead of one INSERT per post. Zero disables COPY.
+	CopyThreshold int
 }
 
 var regexInvalidAuthor, _ = regexp.Compile(`^Key \(user_nn\)=\(([\w\.]+)\) is not present in table "users"\.$`)
 
 func (s *Usecase) AddByThreadSlug(ctx context.Context, posts []Post, slug string) error {
-	dbThread, err := s.Queries.GetThreadBySlug(ctx, pgtype.Text{String: slug, Valid: true})
+	dbThread, err := s.Cache.ThreadBySlug(ctx, slug)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return ErrNotFoundThread
@@ -45,7 +44,7 @@ func (s *Usecase) AddByThreadSlug(ctx context.Context, posts []Post, slug string
 }
 
 func (s *Usecase) AddByThreadId(ctx context.Context, posts []Post, threadId int32) error {
-	dbThread, err := s.Queries.GetThreadByID(ctx, threadId)
+	dbThread, err := s.Cache.ThreadByID(ctx, threadId)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return ErrNotFoundThread
@@ -56,7 +55,15 @@ func (s *Usecase) AddByThreadId(ctx context.Context, posts []Post, threadId int3
 	return s.add(ctx, posts, threadId, dbThread.ForumSlug)
 }
 
+// add inserts the posts, registers their authors as forum users and bumps
+// the forum post counter in one transaction.
 func (s *Usecase) add(ctx context.Context, posts []Post, threadId int32, forumSlug string) error {
+	return s.Tx.Do(ctx, func(queries *db.Queries) error {
+		return s.addTx(ctx, queries, posts, threadId, forumSlug)
+	})
+}
+
+func (s *Usecase) addTx(ctx context.Context, queries *db.Queries, posts []Post, threadId int32, forumSlug string) error {
 	// select parents
 
 	parentIDMap := make(map[int32]struct{})
@@ -67,7 +74,7 @@ func (s *Usecase) add(ctx context.Context, posts []Post, threadId int32, forumSl
 	}
 
 	parentIDs := slices.AppendSeq(make([]int32, 0, len(parentIDMap)), maps.Keys(parentIDMap))
-	parents, err := s.Queries.ListByID(ctx, parentIDs)
+	parents, err := queries.ListByID(ctx, parentIDs)
 	if err != nil {
 		return fmt.Errorf("list parents by id: %w", err)
 	}
@@ -79,10 +86,18 @@ func (s *Usecase) add(ctx context.Context, posts []Post, threadId int32, forumSl
 
 	// insert posts
 
+	// ids are reserved up front: a post's own id is part of its sort key
+	ids, err := queries.NextPostIDs(ctx, int32(len(posts)))
+	if err != nil {
+		return fmt.Errorf("reserve post ids: %w", err)
+	}
+
 	postsParams := make([]db.CreatePostsParams, 0, len(posts))
-	for _, post := range posts {
+	for i, post := range posts {
+		id := ids[i]
 		var parentID pgtype.Int4
 		var path []int32
+		rootID := id
 		if post.Parent != nil {
 			if parent, ok := parentByID[*post.Parent]; ok {
 				if parent.ThreadID != threadId {
@@ -90,38 +105,36 @@ func (s *Usecase) add(ctx context.Context, posts []Post, threadId int32, forumSl
 				}
 
 				parentID = pgtype.Int4{Int32: parent.ID, Valid: true}
-				path = append(parent.Path, parent.ID)
+				path = parent.SortKey
+				rootID = parent.RootID
 			} else {
 				return ErrInvalidParent
 			}
 		}
 
 		postsParams = append(postsParams, db.CreatePostsParams{
+			ID:       id,
 			Message:  *post.Message,
 			ParentID: parentID,
 			UserNn:   *post.Author,
 			ThreadID: threadId,
 			Path:     path,
+			RootID:   rootID,
+			SortKey:  append(slices.Clip(path), id),
 		})
 	}
 
-	postsBatch := s.Queries.CreatePosts(ctx, postsParams)
-	postsBatch.QueryRow(func(i int, post db.Post, batchErr error) {
-		if errors.Is(batchErr, db.ErrBatchAlreadyClosed) {
-			return
-		}
-		if batchErr != nil {
-			var pgErr *pgconn.PgError
-			if errors.As(batchErr, &pgErr) && pgErr.Code == "23503" {
-				err = ErrNotFoundUser{Nickname: post.UserNn}
-			} else {
-				err = batchErr
-			}
-
-			postsBatch.Close()
-			return
-		}
+	var created []db.Post
+	if s.CopyThreshold > 0 && len(postsParams) > s.CopyThreshold {
+		created, err = copyPosts(ctx, queries, postsParams)
+	} else {
+		created, err = insertPosts(ctx, queries, postsParams)
+	}
+	if err != nil {
+		return fmt.Errorf("create posts: %w", err)
+	}
 
+	for i, post := range created {
 		posts[i].Author = &post.UserNn
 		posts[i].Created = &post.Created.Time
 		posts[i].Id = &post.ID
@@ -129,9 +142,6 @@ func (s *Usecase) add(ctx context.Context, posts []Post, threadId int32, forumSl
 		posts[i].Parent = &post.ParentID.Int32
 		posts[i].Thread = &post.ThreadID
 		posts[i].Forum = &forumSlug
-	})
-	if err != nil {
-		return fmt.Errorf("create posts: %w", err)
 	}
 
 	// insert forum users
@@ -144,17 +154,20 @@ func (s *Usecase) add(ctx context.Context, posts []Post, threadId int32, forumSl
 		})
 	}
 
-	forumUsersBatch := s.Queries.CreateForumUser(ctx, forumUsersParams)
+	forumUsersBatch := queries.CreateForumUser(ctx, forumUsersParams)
 	forumUsersBatch.Exec(func(i int, batchErr error) {
-		if batchErr != nil && !errors.Is(batchErr, db.ErrBatchAlreadyClosed) {
+		if batchErr != nil && err == nil {
 			err = batchErr
+			forumUsersBatch.Close()
 		}
-		forumUsersBatch.Close()
 	})
+	if err != nil {
+		return fmt.Errorf("create forum users: %w", err)
+	}
 
 	// update posts count
 
-	err = s.Queries.IncreasePostsCount(ctx, db.IncreasePostsCountParams{
+	err = queries.IncreasePostsCount(ctx, db.IncreasePostsCountParams{
 		NewPostsCount: int32(len(posts)),
 		Slug:          forumSlug,
 	})
@@ -165,33 +178,79 @@ func (s *Usecase) add(ctx context.Context, posts []Post, threadId int32, forumSl
 	return nil
 }
 
-func (s *Usecase) ById(ctx context.Context, id int, related []string) (*PostFull, error) {
-	userObj := user.User{}
-	forumObj := forum.Forum{}
-	postObj := Post{}
-	threadObj := thread.Thread{}
-	result := PostFull{}
-
-	err := s.DB.QueryRow(
-		ctx,
-		`	SELECT 
-					u.about, u.email, u.fullname, u.nickname, 
-					f.posts, f.slug, f.threads, f.title, f.user_nn, 
-					p.user_nn, p.created, f.slug, p.id, p.isedited, p.message, p.parent_id, p.thread_id,
-					t.user_nn, t.created, f.slug, t.id, t.message, t.slug, t.title, t.votes
-				FROM posts p
-					JOIN users u ON p.user_nn = u.nickname
-					JOIN threads t ON p.thread_id = t.id
-					JOIN forums f ON t.forum_slug = f.slug
-				WHERE p.id = $1`,
-		id,
-	).Scan(
-		&userObj.About, &userObj.Email, &userObj.FullName, &userObj.Nickname,
-		&forumObj.Posts, &forumObj.Slug, &forumObj.Threads, &forumObj.Title, &forumObj.User,
-		&postObj.Author, &postObj.Created, &postObj.Forum, &postObj.Id, &postObj.IsEdited, &postObj.Message, &postObj.Parent, &postObj.Thread,
-		&threadObj.Author, &threadObj.Created, &threadObj.Forum, &threadObj.Id, &threadObj.Message, &threadObj.Slug, &threadObj.Title, &threadObj.Votes,
-	)
+// insertPosts queues one INSERT per post and stops at the first failure.
+func insertPosts(ctx context.Context, queries *db.Queries, params []db.CreatePostsParams) ([]db.Post, error) {
+	created := make([]db.Post, len(params))
+
+	var err error
+	batch := queries.CreatePosts(ctx, params)
+	batch.QueryRow(func(i int, post db.Post, batchErr error) {
+		if errors.Is(batchErr, db.ErrBatchAlreadyClosed) {
+			return
+		}
+		if batchErr != nil {
+			var pgErr *pgconn.PgError
+			if errors.As(batchErr, &pgErr) && pgErr.Code == "23503" {
+				err = ErrNotFoundUser{Nickname: params[i].UserNn}
+			} else {
+				err = batchErr
+			}
+
+			batch.Close()
+			return
+		}
 
+		created[i] = post
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return created, nil
+}
+
+// copyPosts writes the posts with a single COPY. COPY returns no rows, so
+// the inserted rows are read back for the column defaults, keeping the
+// result identical to insertPosts.
+func copyPosts(ctx context.Context, queries *db.Queries, params []db.CreatePostsParams) ([]db.Post, error) {
+	rows := make([]db.CopyPostsParams, 0, len(params))
+	ids := make([]int32, 0, len(params))
+	for _, param := range params {
+		rows = append(rows, db.CopyPostsParams(param))
+		ids = append(ids, param.ID)
+	}
+
+	_, err := queries.CopyPosts(ctx, rows)
+	if err != nil {
+		// COPY fails as a whole, the author comes from the error detail
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+			if match := regexInvalidAuthor.FindStringSubmatch(pgErr.Detail); match != nil {
+				return nil, ErrNotFoundUser{Nickname: match[1]}
+			}
+		}
+		return nil, fmt.Errorf("copy posts: %w", err)
+	}
+
+	copied, err := queries.ListByID(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("list copied posts: %w", err)
+	}
+
+	byID := make(map[int32]db.Post, len(copied))
+	for _, post := range copied {
+		byID[post.ID] = post
+	}
+
+	created := make([]db.Post, 0, len(ids))
+	for _, id := range ids {
+		created = append(created, byID[id])
+	}
+	return created, nil
+}
+
+func (s *Usecase) ById(ctx context.Context, id int, related []string) (*PostFull, error) {
+	row, err := s.Queries.GetPostFull(ctx, int32(id))
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, ErrNotFound
@@ -199,266 +258,332 @@ func (s *Usecase) ById(ctx context.Context, id int, related []string) (*PostFull
 		return nil, fmt.Errorf("get post: %w", err)
 	}
 
-	result.Post = &postObj
+	postObj := FromDB(row.Post, row.Forum.Slug)
+	result := PostFull{Post: &postObj}
 	for _, relate := range related {
 		switch relate {
 		case "user":
-			result.Author = &userObj
+			result.Author = user.FromDB(row.User)
 		case "thread":
-			result.Thread = &threadObj
+			result.Thread = thread.FromDB(row.Thread)
 		case "forum":
-			result.Forum = &forumObj
+			result.Forum = forum.FromDB(row.Forum)
+		case "revisions":
+			count, err := s.Queries.CountPostRevisions(ctx, row.Post.ID)
+			if err != nil {
+				return nil, fmt.Errorf("count revisions: %w", err)
+			}
+			result.Revisions = &count
 		}
 	}
 	return &result, nil
 }
 
+// Revisions lists the post's edits, oldest first. The postrevision trigger
+// records them when UpdateById changes the message.
+func (s *Usecase) Revisions(ctx context.Context, id int) (PostRevisions, error) {
+	rows, err := s.Queries.ListPostRevisions(ctx, int32(id))
+	if err != nil {
+		return nil, fmt.Errorf("select revisions: %w", err)
+	}
+
+	if len(rows) == 0 {
+		posts, err := s.Queries.ListByID(ctx, []int32{int32(id)})
+		if err != nil {
+			return nil, fmt.Errorf("select post: %w", err)
+		}
+		if len(posts) == 0 {
+			return nil, ErrNotFound
+		}
+	}
+
+	result := make(PostRevisions, 0, len(rows))
+	for _, row := range rows {
+		result = append(result, RevisionFromDB(row))
+	}
+	return result, nil
+}
+
+// UpdateById replaces the message; the postrevision trigger keeps the
+// previous one.
 func (s *Usecase) UpdateById(ctx context.Context, id int, post PostUpdate) error {
 	if post.Message == nil {
 		return nil
 	}
 
-	_, err := s.DB.Exec(
-		ctx,
-		`	UPDATE posts 
-				SET message = $1, isedited = TRUE
-				WHERE id = $2`,
-		post.Message, id,
-	)
-
+	err := s.Queries.UpdatePostMessage(ctx, db.UpdatePostMessageParams{
+		Message: *post.Message,
+		ID:      int32(id),
+	})
 	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return ErrNotFound
-		}
-		return fmt.Errorf("get post: %w", err)
+		return fmt.Errorf("update post: %w", err)
 	}
 
 	return nil
 }
 
-func (s *Usecase) FlatByThreadSlug(ctx context.Context, slug string, limit int, desc bool, since int) ([]Post, error) {
-	var queryBuilder strings.Builder
-	queryBuilder.WriteString(`	SELECT p.user_nn, p.created, t.forum_slug, p.id, p.message, p.parent_id, p.thread_id
-										FROM posts p
-											JOIN threads t ON p.thread_id = t.id
-										WHERE t.slug = $1`)
+// ByThreadId and ByThreadSlug return a page of the thread's posts in the
+// page's sort mode and the cursor of the next page, nil on the last one.
+func (s *Usecase) ByThreadId(ctx context.Context, id int, page Page) ([]Post, *Cursor, error) {
+	return s.listById(ctx, id, page)
+}
+
+func (s *Usecase) ByThreadSlug(ctx context.Context, slug string, page Page) ([]Post, *Cursor, error) {
+	return s.listBySlug(ctx, slug, page)
+}
 
-	if since != 0 {
-		if desc {
-			queryBuilder.WriteString(" AND p.id < $3")
+// listPosts selects a page of posts of the thread with the given id. For
+// the modes ordered by a key path it also returns the key of the last post,
+// which the next page continues after.
+type listPosts func(ctx context.Context, threadId int32) ([]db.Post, []int32, error)
+
+func (s *Usecase) lister(page Page) listPosts {
+	switch page.Sort {
+	case SortTree:
+		return s.tree(page)
+	case SortParentTree:
+		return s.parentTree(page)
+	case SortTop:
+		return s.top(page)
+	case SortFlatTop:
+		return s.flatTop(page)
+	}
+	return s.flat(page)
+}
+
+func (s *Usecase) flat(page Page) listPosts {
+	var afterCreated pgtype.Timestamptz
+	var afterId pgtype.Int4
+	if page.After != nil && page.After.Created != nil {
+		afterCreated = pgtype.Timestamptz{Time: *page.After.Created, Valid: true}
+		afterId = pgtype.Int4{Int32: page.After.ID, Valid: true}
+	}
+
+	return func(ctx context.Context, threadId int32) ([]db.Post, []int32, error) {
+		params := db.ListPostsFlatParams{
+			ThreadID:     threadId,
+			Since:        sinceParam(page.Since),
+			AfterCreated: afterCreated,
+			AfterID:      afterId,
+			Limit:        int32(page.Limit),
+		}
+		var rows []db.Post
+		var err error
+		if page.Desc {
+			rows, err = s.Queries.ListPostsFlatDesc(ctx, db.ListPostsFlatDescParams(params))
 		} else {
-			queryBuilder.WriteString(" AND p.id > $3")
+			rows, err = s.Queries.ListPostsFlat(ctx, params)
 		}
+		return rows, nil, err
 	}
+}
 
-	if desc {
-		queryBuilder.WriteString(" ORDER BY p.created DESC, p.id DESC LIMIT $2")
-	} else {
-		queryBuilder.WriteString(" ORDER BY p.created, p.id LIMIT $2")
+func (s *Usecase) tree(page Page) listPosts {
+	return func(ctx context.Context, threadId int32) ([]db.Post, []int32, error) {
+		params := db.ListPostsTreeParams{
+			ThreadID:  threadId,
+			Since:     sinceParam(page.Since),
+			AfterPath: afterPath(page.After),
+			Limit:     int32(page.Limit),
+		}
+		var rows []db.Post
+		var err error
+		if page.Desc {
+			rows, err = s.Queries.ListPostsTreeDesc(ctx, db.ListPostsTreeDescParams(params))
+		} else {
+			rows, err = s.Queries.ListPostsTree(ctx, params)
+		}
+		return rows, lastSortKey(rows), err
 	}
-
-	return s.bySlug(ctx, queryBuilder.String(), slug, limit, since)
 }
 
-func (s *Usecase) FlatByThreadId(ctx context.Context, id int, limit int, desc bool, since int) ([]Post, error) {
-	var queryBuilder strings.Builder
-	queryBuilder.WriteString(`	SELECT p.user_nn, p.created, t.forum_slug, p.id, p.message, p.parent_id, p.thread_id
-										FROM posts p
-											JOIN threads t ON p.thread_id = t.id
-										WHERE t.id = $1`)
+func (s *Usecase) parentTree(page Page) listPosts {
+	return func(ctx context.Context, threadId int32) ([]db.Post, []int32, error) {
+		params := db.ListPostsParentTreeParams{
+			AfterPath: afterPath(page.After),
+			Since:     sinceParam(page.Since),
+			ThreadID:  threadId,
+			Limit:     int32(page.Limit),
+		}
 
-	if since != 0 {
-		if desc {
-			queryBuilder.WriteString(" AND p.id < $3")
+		var posts []db.Post
+		if page.Desc {
+			rows, err := s.Queries.ListPostsParentTreeDesc(ctx, db.ListPostsParentTreeDescParams(params))
+			if err != nil {
+				return nil, nil, err
+			}
+			for _, row := range rows {
+				posts = append(posts, row.Post)
+			}
 		} else {
-			queryBuilder.WriteString(" AND p.id > $3")
+			rows, err := s.Queries.ListPostsParentTree(ctx, params)
+			if err != nil {
+				return nil, nil, err
+			}
+			for _, row := range rows {
+				posts = append(posts, row.Post)
+			}
 		}
+		return posts, lastSortKey(posts), nil
 	}
-
-	if desc {
-		queryBuilder.WriteString(" ORDER BY p.created DESC, p.id DESC LIMIT $2")
-	} else {
-		queryBuilder.WriteString(" ORDER BY p.created, p.id LIMIT $2")
-	}
-
-	return s.byId(ctx, queryBuilder.String(), id, limit, since)
 }
 
-func (s *Usecase) TreeByThreadSlug(ctx context.Context, slug string, limit int, desc bool, since int) ([]Post, error) {
-	var queryBuilder strings.Builder
-	queryBuilder.WriteString(
-		`	SELECT p.user_nn, p.created, t.forum_slug, p.id, p.message, p.parent_id, p.thread_id
-    			FROM posts p JOIN threads t ON t.id = p.thread_id`,
-	)
+func (s *Usecase) top(page Page) listPosts {
+	return func(ctx context.Context, threadId int32) ([]db.Post, []int32, error) {
+		params := db.ListPostsTopParams{
+			ThreadID:  threadId,
+			AfterRank: afterPath(page.After),
+			Since:     sinceParam(page.Since),
+			Limit:     int32(page.Limit),
+		}
 
-	if since != 0 {
-		if desc {
-			queryBuilder.WriteString(" JOIN posts ON posts.id = $3 WHERE p.path || p.id < posts.path || posts.id")
+		var posts []db.Post
+		var rankKey []int32
+		if page.Desc {
+			rows, err := s.Queries.ListPostsTopDesc(ctx, db.ListPostsTopDescParams(params))
+			if err != nil {
+				return nil, nil, err
+			}
+			for _, row := range rows {
+				posts = append(posts, row.Post)
+				rankKey = row.RankKey
+			}
 		} else {
-			queryBuilder.WriteString(" JOIN posts ON posts.id = $3 WHERE p.path || p.id > posts.path || posts.id")
+			rows, err := s.Queries.ListPostsTop(ctx, params)
+			if err != nil {
+				return nil, nil, err
+			}
+			for _, row := range rows {
+				posts = append(posts, row.Post)
+				rankKey = row.RankKey
+			}
 		}
-		queryBuilder.WriteString(" AND t.slug = $1 ORDER BY p.path || p.id")
-	} else {
-		queryBuilder.WriteString(" WHERE t.slug = $1 ORDER BY p.path || p.id")
-	}
-
-	if desc {
-		queryBuilder.WriteString(" DESC")
+		return posts, rankKey, nil
 	}
-	queryBuilder.WriteString(" LIMIT $2")
-
-	return s.bySlug(ctx, queryBuilder.String(), slug, limit, since)
 }
 
-func (s *Usecase) TreeByThreadId(ctx context.Context, id int, limit int, desc bool, since int) ([]Post, error) {
-	var queryBuilder strings.Builder
-	queryBuilder.WriteString(
-		`	SELECT p.user_nn, p.created, (SELECT forum_slug FROM threads WHERE id = $1), p.id, p.message, p.parent_id, p.thread_id
-    			FROM posts p`,
-	)
+func (s *Usecase) flatTop(page Page) listPosts {
+	return func(ctx context.Context, threadId int32) ([]db.Post, []int32, error) {
+		params := db.ListPostsFlatTopParams{
+			AfterRank: afterPath(page.After),
+			Since:     sinceParam(page.Since),
+			ThreadID:  threadId,
+			Limit:     int32(page.Limit),
+		}
 
-	if since != 0 {
-		if desc {
-			queryBuilder.WriteString(" JOIN posts ON posts.id = $3 WHERE p.path || p.id < posts.path || posts.id")
+		var posts []db.Post
+		if page.Desc {
+			rows, err := s.Queries.ListPostsFlatTopDesc(ctx, db.ListPostsFlatTopDescParams(params))
+			if err != nil {
+				return nil, nil, err
+			}
+			for _, row := range rows {
+				posts = append(posts, row.Post)
+			}
 		} else {
-			queryBuilder.WriteString(" JOIN posts ON posts.id = $3 WHERE p.path || p.id > posts.path || posts.id")
+			rows, err := s.Queries.ListPostsFlatTop(ctx, params)
+			if err != nil {
+				return nil, nil, err
+			}
+			for _, row := range rows {
+				posts = append(posts, row.Post)
+			}
 		}
-		queryBuilder.WriteString(" AND p.thread_id = $1 ORDER BY p.path || p.id")
-	} else {
-		queryBuilder.WriteString(" WHERE p.thread_id = $1 ORDER BY p.path || p.id")
-	}
-
-	if desc {
-		queryBuilder.WriteString(" DESC")
+		if len(posts) == 0 {
+			return posts, nil, nil
+		}
+		last := posts[len(posts)-1]
+		return posts, []int32{-last.Votes, last.ID}, nil
 	}
-	queryBuilder.WriteString(" LIMIT $2")
-
-	return s.byId(ctx, queryBuilder.String(), id, limit, since)
 }
-func (s *Usecase) ParentTreeByThreadSlug(ctx context.Context, slug string, limit int, desc bool, since int) ([]Post, error) {
-	var queryBuilder strings.Builder
-	queryBuilder.WriteString("WITH ranked_posts AS (SELECT p.user_nn, p.created, t.forum_slug, p.id, p.message, p.parent_id, p.thread_id,p.path || p.id AS path,")
-
-	if desc {
-		queryBuilder.WriteString(" dense_rank() over (ORDER BY COALESCE(path [1], p.id) desc) AS rank")
-	} else {
-		queryBuilder.WriteString(" dense_rank() over (ORDER BY COALESCE(path [1], p.id)) AS rank")
-	}
-	queryBuilder.WriteString(
-		`	FROM posts p JOIN threads t on p.thread_id = t.id WHERE t.slug = $1)
-				SELECT p.user_nn, p.created, p.forum_slug, p.id, p.message, p.parent_id, p.thread_id 
-				FROM ranked_posts p`)
-
-	if since != 0 {
-		queryBuilder.WriteString(
-			`	JOIN ranked_posts posts ON posts.id = $3 
-				WHERE p.rank <= $2 + posts.rank AND (p.rank > posts.rank OR p.rank = posts.rank AND p.path > posts.path) 
-				ORDER BY p.rank, p.path`)
-	} else {
-		queryBuilder.WriteString(" WHERE p.rank <= $2 ORDER BY p.rank, p.path")
-	}
 
-	return s.bySlug(ctx, queryBuilder.String(), slug, limit, since)
+// sinceParam maps the zero since, which means no since, to NULL.
+func sinceParam(since int) pgtype.Int4 {
+	return pgtype.Int4{Int32: int32(since), Valid: since != 0}
 }
 
-func (s *Usecase) ParentTreeByThreadId(ctx context.Context, id int, limit int, desc bool, since int) ([]Post, error) {
-	var queryBuilder strings.Builder
-	queryBuilder.WriteString("WITH ranked_posts AS (SELECT p.user_nn, p.created, t.forum_slug, p.id, p.message, p.parent_id, p.thread_id,p.path || p.id AS path,")
-
-	if desc {
-		queryBuilder.WriteString(" dense_rank() over (ORDER BY COALESCE(path [1], p.id) desc) AS rank")
-	} else {
-		queryBuilder.WriteString(" dense_rank() over (ORDER BY COALESCE(path [1], p.id)) AS rank")
-	}
-	queryBuilder.WriteString(
-		`	FROM posts p JOIN threads t on p.thread_id = t.id WHERE t.id = $1)
-				SELECT p.user_nn, p.created, p.forum_slug, p.id, p.message, p.parent_id, p.thread_id 
-				FROM ranked_posts p`)
-
-	if since != 0 {
-		queryBuilder.WriteString(
-			`	JOIN ranked_posts posts ON posts.id = $3 
-				WHERE p.rank <= $2 + posts.rank AND (p.rank > posts.rank OR p.rank = posts.rank AND p.path > posts.path) 
-				ORDER BY p.rank, p.path`)
-	} else {
-		queryBuilder.WriteString(" WHERE p.rank <= $2 ORDER BY p.rank, p.path")
+func lastSortKey(rows []db.Post) []int32 {
+	if len(rows) == 0 {
+		return nil
 	}
-
-	return s.byId(ctx, queryBuilder.String(), id, limit, since)
+	return rows[len(rows)-1].SortKey
 }
 
-func (s *Usecase) byId(ctx context.Context, query string, id int, limit int, since int) ([]Post, error) {
-	var rows pgx.Rows
-	var err error
-	if since != 0 {
-		rows, err = s.DB.Query(ctx, query, id, limit, since)
-	} else {
-		rows, err = s.DB.Query(ctx, query, id, limit)
+// afterPath returns the anchor of a tree cursor, nil without one.
+func afterPath(after *Cursor) []int32 {
+	if after == nil {
+		return nil
 	}
+	return after.Path
+}
 
-	if err != nil {
-		return nil, fmt.Errorf("get post by id: %w", err)
+// nextCursor returns the position after the last post of a full page. A
+// parent_tree page is full when it holds limit root posts, the rest of the
+// anchor's tree not counting. lastKey is the key of the last post returned
+// by the lister.
+func nextCursor(page Page, rows []db.Post, lastKey []int32) *Cursor {
+	if len(rows) == 0 {
+		return nil
 	}
-	defer rows.Close()
 
-	posts := make([]Post, 0, 1)
-	for rows.Next() {
-		var post Post
-		err = rows.Scan(&post.Author, &post.Created, &post.Forum, &post.Id, &post.Message, &post.Parent, &post.Thread)
-		if err != nil {
-			return nil, fmt.Errorf("scan posts: %w", err)
+	count := len(rows)
+	if page.Sort == SortParentTree {
+		count = 0
+		for _, row := range rows {
+			if !row.ParentID.Valid {
+				count++
+			}
 		}
-		posts = append(posts, post)
-	}
-	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("scan posts: %w", err)
 	}
-	rows.Close()
-
-	if len(posts) == 0 {
-		err := s.DB.QueryRow(ctx, "SELECT id FROM threads WHERE id = $1", id).Scan(&id)
-		if err != nil {
-			return nil, ErrNotFoundThread
-		}
+	if count < page.Limit {
+		return nil
 	}
 
-	return posts, nil
-}
-
-func (s *Usecase) bySlug(ctx context.Context, query string, slug string, limit int, since int) ([]Post, error) {
-	var rows pgx.Rows
-	var err error
-	if since != 0 {
-		rows, err = s.DB.Query(ctx, query, slug, limit, since)
+	last := rows[len(rows)-1]
+	next := &Cursor{Sort: page.Sort, Desc: page.Desc}
+	if page.Sort == SortFlat {
+		next.ID = last.ID
+		next.Created = &last.Created.Time
 	} else {
-		rows, err = s.DB.Query(ctx, query, slug, limit)
+		next.Path = lastKey
 	}
+	return next
+}
 
+// listById and listBySlug resolve the thread first, so an empty page of an
+// existing thread is told apart from a missing thread without a second
+// lookup, and the forum slug comes from the thread row.
+func (s *Usecase) listById(ctx context.Context, id int, page Page) ([]Post, *Cursor, error) {
+	dbThread, err := s.Cache.ThreadByID(ctx, int32(id))
 	if err != nil {
-		return nil, fmt.Errorf("get post by slug: %w", err)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil, ErrNotFoundThread
+		}
+		return nil, nil, fmt.Errorf("select thread: %w", err)
 	}
-	defer rows.Close()
+	return s.list(ctx, dbThread, page)
+}
 
-	posts := make([]Post, 0, 1)
-	for rows.Next() {
-		var post Post
-		err = rows.Scan(&post.Author, &post.Created, &post.Forum, &post.Id, &post.Message, &post.Parent, &post.Thread)
-		if err != nil {
-			return nil, fmt.Errorf("get post by slug: %w", err)
+func (s *Usecase) listBySlug(ctx context.Context, slug string, page Page) ([]Post, *Cursor, error) {
+	dbThread, err := s.Cache.ThreadBySlug(ctx, slug)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil, ErrNotFoundThread
 		}
-		posts = append(posts, post)
-	}
-	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("scan posts: %w", err)
+		return nil, nil, fmt.Errorf("select thread: %w", err)
 	}
-	rows.Close()
+	return s.list(ctx, dbThread, page)
+}
 
-	if len(posts) == 0 {
-		err := s.DB.QueryRow(ctx, "SELECT slug FROM threads WHERE slug = $1", slug).Scan(&slug)
-		if err != nil {
-			return nil, ErrNotFoundThread
-		}
+func (s *Usecase) list(ctx context.Context, dbThread cache.Thread, page Page) ([]Post, *Cursor, error) {
+	rows, lastKey, err := s.lister(page)(ctx, dbThread.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("select posts: %w", err)
 	}
 
-	return posts, nil
+	posts := make([]Post, 0, len(rows))
+	for _, row := range rows {
+		posts = append(posts, FromDB(row, dbThread.ForumSlug))
+	}
+	return posts, nextCursor(page, rows, lastKey), nil
 }
@@ -0,0 +1,37 @@
+package status
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/viewsharp/technopark-forum/internal/cache"
+	"github.com/viewsharp/technopark-forum/internal/db"
+)
+
+type Usecase struct {
+	Queries *db.Queries
+	Cache   *cache.Entities
+}
+
+func (s *Usecase) Get(ctx context.Context) (*Status, error) {
+	counts, err := s.Queries.GetStatus(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("count rows: %w", err)
+	}
+
+	return &Status{
+		Forum:  &counts.Forum,
+		Post:   &counts.Post,
+		Thread: &counts.Thread,
+		User:   &counts.User,
+	}, nil
+}
+
+func (s *Usecase) Clear(ctx context.Context) error {
+	err := s.Queries.ClearAll(ctx)
+	if err != nil {
+		return fmt.Errorf("truncate tables: %w", err)
+	}
+	s.Cache.Purge()
+	return nil
+}
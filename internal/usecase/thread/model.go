@@ -1,22 +1,94 @@
 package thread
 
-import "time"
+import (
+	"time"
+
+	"github.com/viewsharp/technopark-forum/internal/db"
+)
 
 type Thread struct {
-	Author  *string    `json:"author"`
+	Author  *string    `json:"author" validate:"required"`
 	Created *time.Time `json:"created,omitempty"`
 	Forum   *string    `json:"forum,omitempty"`
 	Id      *int32     `json:"id,omitempty"`
-	Message *string    `json:"message"`
-	Slug    *string    `json:"slug,omitempty"`
-	Title   *string    `json:"title"`
+	Message *string    `json:"message" validate:"required"`
+	Slug    *string    `json:"slug,omitempty" validate:"slug"`
+	Title   *string    `json:"title" validate:"required"`
 	Votes   *int32     `json:"votes,omitempty"`
 }
 
+// FromDB converts a threads row, leaving NULL columns unset.
+func FromDB(t db.Thread) *Thread {
+	thread := &Thread{
+		Author: &t.UserNn,
+		Forum:  &t.ForumSlug,
+		Id:     &t.ID,
+		Title:  &t.Title,
+	}
+	if t.Created.Valid {
+		thread.Created = &t.Created.Time
+	}
+	if t.Message.Valid {
+		thread.Message = &t.Message.String
+	}
+	if t.Slug.Valid {
+		thread.Slug = &t.Slug.String
+	}
+	if t.Votes.Valid {
+		thread.Votes = &t.Votes.Int32
+	}
+	return thread
+}
+
 //easyjson:json
 type Threads []*Thread
 
+// Page selects one page of a forum's threads. Since is an inclusive bound
+// on the creation time; After continues right behind a previous page.
+type Page struct {
+	Desc  bool
+	Since *time.Time
+	After *Cursor
+	Limit int
+}
+
+// Cursor is the keyset position of the last thread of a page. Threads
+// created at the same time are ordered by id, so none is repeated or
+// skipped across pages.
+type Cursor struct {
+	Created time.Time `json:"created"`
+	ID      int32     `json:"id"`
+	Desc    bool      `json:"desc"`
+}
+
+// ThreadRevision is the title and message of a thread before the edit made at
+// Edited.
+type ThreadRevision struct {
+	Id      int32     `json:"id"`
+	Title   string    `json:"title"`
+	Message *string   `json:"message,omitempty"`
+	Edited  time.Time `json:"edited"`
+}
+
+func RevisionFromDB(r db.ThreadRevision) ThreadRevision {
+	revision := ThreadRevision{Id: r.ID, Title: r.Title, Edited: r.Edited.Time}
+	if r.Message.Valid {
+		revision.Message = &r.Message.String
+	}
+	return revision
+}
+
+type ThreadRevisions []ThreadRevision
+
+// LastModified returns the time of the latest edit, as for post revisions.
+func (r ThreadRevisions) LastModified() time.Time {
+	if len(r) == 0 {
+		return time.Time{}
+	}
+	return r[len(r)-1].Edited
+}
+
 type ThreadUpdate struct {
 	Message *string `json:"message,omitempty"`
 	Title   *string `json:"title,omitempty"`
-}
\ No newline at end of file
+}
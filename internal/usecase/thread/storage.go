@@ -4,204 +4,220 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"strings"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
-)
+	"github.com/jackc/pgx/v5/pgtype"
 
-type DB interface {
-	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
-	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
-	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
-}
+	"github.com/viewsharp/technopark-forum/internal/cache"
+	"github.com/viewsharp/technopark-forum/internal/db"
+)
 
 type Usecase struct {
-	DB DB
+	Queries *db.Queries
+	Cache   *cache.Entities
 }
 
+// Add creates the thread. The forum counter and forum users are updated by
+// the threadinsert trigger as part of the same statement.
 func (s *Usecase) Add(ctx context.Context, thread *Thread) error {
-	err := s.DB.QueryRow(
-		ctx,
-		`	INSERT INTO threads (slug, created, title, message, user_nn, forum_slug)
-            	VALUES ($1, $2, $3, $4, $5, (SELECT slug FROM forums WHERE slug = $6))
-              	RETURNING id, forum_slug, slug`,
-		thread.Slug, thread.Created, thread.Title, thread.Message, thread.Author, thread.Forum,
-	).Scan(&thread.Id, &thread.Forum, &thread.Slug)
+	params := db.CreateThreadParams{
+		Title:   *thread.Title,
+		UserNn:  *thread.Author,
+		Forum:   *thread.Forum,
+		Slug:    textOrNull(thread.Slug),
+		Message: textOrNull(thread.Message),
+	}
+	if thread.Created != nil {
+		params.Created = pgtype.Timestamptz{Time: *thread.Created, Valid: true}
+	}
 
+	created, err := s.Queries.CreateThread(ctx, params)
 	if err != nil {
 		var pgErr *pgconn.PgError
 		if errors.As(err, &pgErr) {
-			switch pgErr.Code {
-			case "23502":
+			switch {
+			// forum_slug is looked up by a subquery, so a missing forum
+			// surfaces as a NULL in a NOT NULL column
+			case pgErr.Code == "23502" && pgErr.ColumnName == "forum_slug":
+				return ErrNotFoundForum
+			case pgErr.Code == "23503" && pgErr.ConstraintName == "threads_user_nn_fkey":
 				return ErrNotFoundUser
-			case "23503":
+			case pgErr.Code == "23503" && pgErr.ConstraintName == "threads_forum_slug_fkey":
 				return ErrNotFoundForum
-			case "23505":
+			case pgErr.Code == "23505":
 				return ErrUniqueViolation
 			}
 		}
 		return fmt.Errorf("insert threads: %w", err)
 	}
+
+	thread.Id = &created.ID
+	thread.Forum = &created.ForumSlug
+	if created.Slug.Valid {
+		thread.Slug = &created.Slug.String
+	} else {
+		thread.Slug = nil
+	}
 	return nil
 }
 
 func (s *Usecase) BySlug(ctx context.Context, slug string) (*Thread, error) {
-	var result Thread
-
-	err := s.DB.QueryRow(
-		ctx,
-		`	SELECT id, slug, created, title, message, user_nn, forum_slug, votes
-            	FROM threads
-              	WHERE slug = $1`,
-		slug,
-	).Scan(&result.Id, &result.Slug, &result.Created, &result.Title, &result.Message, &result.Author, &result.Forum, &result.Votes)
-
+	result, err := s.Queries.GetThreadBySlug(ctx, pgtype.Text{String: slug, Valid: true})
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, ErrNotFound
 		}
 		return nil, fmt.Errorf("get thread: %w", err)
 	}
-	return &result, nil
+	return FromDB(result), nil
 }
 
 func (s *Usecase) ById(ctx context.Context, id int) (*Thread, error) {
-	var result Thread
-
-	err := s.DB.QueryRow(
-		ctx,
-		`	SELECT id, slug, created, title, message, user_nn, forum_slug, votes
-            	FROM threads
-              	WHERE id = $1`,
-		id,
-	).Scan(&result.Id, &result.Slug, &result.Created, &result.Title, &result.Message, &result.Author, &result.Forum, &result.Votes)
-
+	result, err := s.Queries.GetThreadByID(ctx, int32(id))
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, ErrNotFound
 		}
 		return nil, fmt.Errorf("get thread: %w", err)
 	}
-	return &result, nil
+	return FromDB(result), nil
 }
 
-func (s *Usecase) ByForumSlug(ctx context.Context, slug string, desc bool, since string, limit int) (*Threads, error) {
-	var queryBuilder strings.Builder
-	queryBuilder.WriteString(`	SELECT id, slug, created, title, message, user_nn, forum_slug, votes
-            						FROM threads t
-									WHERE forum_slug = $1`)
-
-	if since != "" {
-		if desc {
-			queryBuilder.WriteString(" AND created <= $3")
-		} else {
-			queryBuilder.WriteString(" AND created >= $3")
-		}
+// ByForumSlug returns a page of the forum's threads and the cursor of the
+// next one, nil when the page is the last.
+func (s *Usecase) ByForumSlug(ctx context.Context, slug string, page Page) (*Threads, *Cursor, error) {
+	var since, afterCreated pgtype.Timestamptz
+	var afterID pgtype.Int4
+	if page.Since != nil {
+		since = pgtype.Timestamptz{Time: *page.Since, Valid: true}
 	}
-
-	queryBuilder.WriteString(" ORDER BY created")
-	if desc {
-		queryBuilder.WriteString(" DESC")
+	if page.After != nil {
+		afterCreated = pgtype.Timestamptz{Time: page.After.Created, Valid: true}
+		afterID = pgtype.Int4{Int32: page.After.ID, Valid: true}
 	}
 
-	queryBuilder.WriteString(" LIMIT $2")
-
-	var rows pgx.Rows
+	var rows []db.Thread
 	var err error
-	if since == "" {
-		rows, err = s.DB.Query(ctx, queryBuilder.String(), slug, limit)
+	if page.Desc {
+		rows, err = s.Queries.ListThreadsByForumDesc(ctx, db.ListThreadsByForumDescParams{
+			ForumSlug:    slug,
+			Since:        since,
+			AfterCreated: afterCreated,
+			AfterID:      afterID,
+			Limit:        int32(page.Limit),
+		})
 	} else {
-		rows, err = s.DB.Query(ctx, queryBuilder.String(), slug, limit, since)
+		rows, err = s.Queries.ListThreadsByForum(ctx, db.ListThreadsByForumParams{
+			ForumSlug:    slug,
+			Since:        since,
+			AfterCreated: afterCreated,
+			AfterID:      afterID,
+			Limit:        int32(page.Limit),
+		})
 	}
 	if err != nil {
-		return nil, fmt.Errorf("select thread: %w", err)
-	}
-	defer rows.Close()
-
-	result := make(Threads, 0, limit)
-	for rows.Next() {
-		var thread Thread
-		err = rows.Scan(
-			&thread.Id,
-			&thread.Slug,
-			&thread.Created,
-			&thread.Title,
-			&thread.Message,
-			&thread.Author,
-			&thread.Forum,
-			&thread.Votes,
-		)
+		return nil, nil, fmt.Errorf("select thread: %w", err)
+	}
+
+	if len(rows) == 0 {
+		_, err = s.Cache.ForumBySlug(ctx, slug)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil, ErrNotFoundForum
+		}
 		if err != nil {
-			return nil, fmt.Errorf("scan thread: %w", err)
+			return nil, nil, fmt.Errorf("select forum: %w", err)
 		}
-
-		result = append(result, &thread)
 	}
-	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("scan threads: %w", err)
+
+	result := make(Threads, 0, len(rows))
+	for _, row := range rows {
+		result = append(result, FromDB(row))
 	}
-	rows.Close()
 
-	if len(result) == 0 {
-		var forumSlug *string
-		err = s.DB.QueryRow(ctx, "SELECT slug FROM forums WHERE slug = $1", slug).Scan(&forumSlug)
-		if forumSlug == nil {
-			return nil, ErrNotFoundForum
-		}
+	var next *Cursor
+	if len(rows) > 0 && len(rows) == page.Limit {
+		last := rows[len(rows)-1]
+		next = &Cursor{Created: last.Created.Time, ID: last.ID, Desc: page.Desc}
 	}
+	return &result, next, nil
+}
 
-	return &result, nil
+func (s *Usecase) UpdateById(ctx context.Context, id int, thread *ThreadUpdate) (*Thread, error) {
+	result, err := s.Queries.UpdateThreadByID(ctx, db.UpdateThreadByIDParams{
+		Title:   textOrNull(thread.Title),
+		Message: textOrNull(thread.Message),
+		ID:      int32(id),
+	})
+	if err != nil {
+		return nil, updateError(err)
+	}
+	return FromDB(result), nil
 }
 
-func (s *Usecase) UpdateById(ctx context.Context, id int, thread *ThreadUpdate) error {
-	_, err := s.DB.Exec(
-		ctx,
-		`	UPDATE threads 
-				SET title = COALESCE($1, title), message = COALESCE($2, message)
-				WHERE id = $3
-				RETURNING title, message`,
-		thread.Title, thread.Message, id,
-	)
+func (s *Usecase) UpdateBySlug(ctx context.Context, slug string, thread *ThreadUpdate) (*Thread, error) {
+	result, err := s.Queries.UpdateThreadBySlug(ctx, db.UpdateThreadBySlugParams{
+		Title:   textOrNull(thread.Title),
+		Message: textOrNull(thread.Message),
+		Slug:    pgtype.Text{String: slug, Valid: true},
+	})
+	if err != nil {
+		return nil, updateError(err)
+	}
+	return FromDB(result), nil
+}
 
+// RevisionsById and RevisionsBySlug list the thread's edits, oldest first.
+// The threadrevision trigger records them on update.
+func (s *Usecase) RevisionsById(ctx context.Context, id int) (ThreadRevisions, error) {
+	result, err := s.Cache.ThreadByID(ctx, int32(id))
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return ErrNotFound
-		}
-		var pgErr *pgconn.PgError
-		if errors.As(err, &pgErr) {
-			switch pgErr.Code {
-			case "23505":
-				return ErrUniqueViolation
-			}
+			return nil, ErrNotFound
 		}
-		return fmt.Errorf("select forum by slug: %w", err)
+		return nil, fmt.Errorf("get thread: %w", err)
 	}
-	return nil
+	return s.revisions(ctx, result.ID)
 }
 
-func (s *Usecase) UpdateBySlug(ctx context.Context, slug string, thread *ThreadUpdate) error {
-	_, err := s.DB.Exec(
-		ctx,
-		`	UPDATE threads 
-				SET title = COALESCE($1, title), message = COALESCE($2, message)
-				WHERE slug = $3
-				RETURNING title, message`,
-		thread.Title, thread.Message, slug,
-	)
-
+func (s *Usecase) RevisionsBySlug(ctx context.Context, slug string) (ThreadRevisions, error) {
+	result, err := s.Cache.ThreadBySlug(ctx, slug)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return ErrNotFound
-		}
-		var pgErr *pgconn.PgError
-		if errors.As(err, &pgErr) {
-			switch pgErr.Code {
-			case "23505":
-				return ErrUniqueViolation
-			}
+			return nil, ErrNotFound
 		}
-		return fmt.Errorf("select forum by slug: %w", err)
+		return nil, fmt.Errorf("get thread: %w", err)
 	}
-	return nil
+	return s.revisions(ctx, result.ID)
+}
+
+func (s *Usecase) revisions(ctx context.Context, id int32) (ThreadRevisions, error) {
+	rows, err := s.Queries.ListThreadRevisions(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("select revisions: %w", err)
+	}
+
+	result := make(ThreadRevisions, 0, len(rows))
+	for _, row := range rows {
+		result = append(result, RevisionFromDB(row))
+	}
+	return result, nil
+}
+
+func updateError(err error) error {
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrNotFound
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return ErrUniqueViolation
+	}
+	return fmt.Errorf("update thread: %w", err)
+}
+
+func textOrNull(s *string) pgtype.Text {
+	if s == nil {
+		return pgtype.Text{}
+	}
+	return pgtype.Text{String: *s, Valid: true}
 }
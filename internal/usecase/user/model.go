@@ -1,16 +1,47 @@
 package user
 
+import "github.com/viewsharp/technopark-forum/internal/db"
+
 type User struct {
 	About    *string `json:"about,omitempty"`
-	Email    *string `json:"email"`
-	FullName *string `json:"fullname"`
-	Nickname *string `json:"nickname,omitempty"`
+	Email    *string `json:"email" validate:"required,email"`
+	FullName *string `json:"fullname" validate:"required"`
+	Nickname *string `json:"nickname,omitempty" validate:"required"`
+}
+
+// FromDB converts a users row, leaving a NULL about unset.
+func FromDB(u db.User) *User {
+	user := &User{
+		Email:    &u.Email,
+		FullName: &u.Fullname,
+		Nickname: &u.Nickname,
+	}
+	if u.About.Valid {
+		user.About = &u.About.String
+	}
+	return user
 }
 
 type Users []*User
 
+// Page selects one page of a forum's users. Since and After both continue
+// after a nickname; After is the cursor handed out with the previous page.
+type Page struct {
+	Desc  bool
+	Since string
+	After *Cursor
+	Limit int
+}
+
+// Cursor is the keyset position of the last user of a page. Nicknames are
+// unique, so they order users without a tie-breaker.
+type Cursor struct {
+	Nickname string `json:"nickname"`
+	Desc     bool   `json:"desc"`
+}
+
 type UserUpdate struct {
 	About    *string `json:"about,omitempty"`
-	Email    *string `json:"email,omitempty"`
+	Email    *string `json:"email,omitempty" validate:"email"`
 	FullName *string `json:"fullname,omitempty"`
 }
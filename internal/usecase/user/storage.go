@@ -4,28 +4,27 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"strings"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
-)
+	"github.com/jackc/pgx/v5/pgtype"
 
-type DB interface {
-	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
-	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
-	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
-}
+	"github.com/viewsharp/technopark-forum/internal/cache"
+	"github.com/viewsharp/technopark-forum/internal/db"
+)
 
 type Usecase struct {
-	DB DB
+	Queries *db.Queries
+	Cache   *cache.Entities
 }
 
 func (s *Usecase) Add(ctx context.Context, user *User) error {
-	_, err := s.DB.Exec(
-		ctx,
-		"INSERT INTO users (nickname, fullname, email, about)	VALUES ($1, $2, $3, $4)",
-		user.Nickname, user.FullName, user.Email, user.About,
-	)
+	err := s.Queries.CreateUser(ctx, db.CreateUserParams{
+		Nickname: *user.Nickname,
+		Fullname: *user.FullName,
+		Email:    *user.Email,
+		About:    textOrNull(user.About),
+	})
 	if err != nil {
 		var pgErr *pgconn.PgError
 		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
@@ -38,13 +37,7 @@ func (s *Usecase) Add(ctx context.Context, user *User) error {
 }
 
 func (s *Usecase) ByNickname(ctx context.Context, nickname string) (*User, error) {
-	var result User
-
-	err := s.DB.QueryRow(
-		ctx,
-		"SELECT nickname, fullname, email, about FROM users WHERE nickname = $1",
-		nickname,
-	).Scan(&result.Nickname, &result.FullName, &result.Email, &result.About)
+	result, err := s.Queries.GetUserByNickname(ctx, nickname)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, ErrNotFound
@@ -52,29 +45,25 @@ func (s *Usecase) ByNickname(ctx context.Context, nickname string) (*User, error
 		return nil, fmt.Errorf("select user: %w", err)
 	}
 
-	return &result, nil
+	return FromDB(result), nil
 }
 
 func (s *Usecase) ByEmail(ctx context.Context, email string) (*User, error) {
-	var result User
-
-	err := s.DB.QueryRow(ctx, "SELECT nickname, fullname, email, about FROM users WHERE email = $1", email).Scan(&result.Nickname, &result.FullName, &result.Email, &result.About)
+	result, err := s.Queries.GetUserByEmail(ctx, email)
 	if err != nil {
 		return nil, fmt.Errorf("select user: %w", err)
 	}
 
-	return &result, nil
+	return FromDB(result), nil
 }
 
 func (s *Usecase) UpdateByNickname(ctx context.Context, nickname string, user *UserUpdate) error {
-	err := s.DB.QueryRow(
-		ctx,
-		"UPDATE users "+
-			"SET fullname = COALESCE($1, fullname), email = COALESCE($2, email), about = COALESCE($3, about) "+
-			"WHERE nickname = $4 "+
-			"RETURNING fullname, email, about",
-		user.FullName, user.Email, user.About, nickname,
-	).Scan(&user.FullName, &user.Email, &user.About)
+	result, err := s.Queries.UpdateUser(ctx, db.UpdateUserParams{
+		Fullname: textOrNull(user.FullName),
+		Email:    textOrNull(user.Email),
+		About:    textOrNull(user.About),
+		Nickname: nickname,
+	})
 	if err != nil {
 		var pgErr *pgconn.PgError
 		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
@@ -86,68 +75,65 @@ func (s *Usecase) UpdateByNickname(ctx context.Context, nickname string, user *U
 		return fmt.Errorf("update user: %w", err)
 	}
 
+	updated := FromDB(result)
+	user.FullName, user.Email, user.About = updated.FullName, updated.Email, updated.About
 	return nil
 }
 
-func (s *Usecase) ByForumSlug(ctx context.Context, slug string, desc bool, since string, limit int) (*Users, error) {
-	var queryBuilder strings.Builder
-	queryBuilder.WriteString(
-		"SELECT u.nickname, u.fullname, u.email, u.about " +
-			"FROM forum_user fu " +
-			"JOIN users u ON fu.user_id = u.id " +
-			"WHERE fu.forum_slug = $1")
-
-	if since != "" {
-		if desc {
-			queryBuilder.WriteString(" AND nickname < $3")
-		} else {
-			queryBuilder.WriteString(" AND nickname > $3")
-		}
-	}
-
-	queryBuilder.WriteString(" ORDER BY nickname")
-	if desc {
-		queryBuilder.WriteString(" DESC")
+// ByForumSlug returns a page of the forum's users and the cursor of the next
+// one, nil when the page is the last.
+func (s *Usecase) ByForumSlug(ctx context.Context, slug string, page Page) (*Users, *Cursor, error) {
+	since := page.Since
+	if page.After != nil {
+		since = page.After.Nickname
 	}
+	sinceParam := pgtype.Text{String: since, Valid: since != ""}
+	limit := page.Limit
 
-	queryBuilder.WriteString(" LIMIT $2")
-
-	var rows pgx.Rows
+	var rows []db.User
 	var err error
-	if since == "" {
-		rows, err = s.DB.Query(ctx, queryBuilder.String(), slug, limit)
+	if page.Desc {
+		rows, err = s.Queries.ListUsersByForumDesc(ctx, db.ListUsersByForumDescParams{
+			ForumSlug: slug,
+			Since:     sinceParam,
+			Limit:     int32(limit),
+		})
 	} else {
-		rows, err = s.DB.Query(ctx, queryBuilder.String(), slug, limit, since)
+		rows, err = s.Queries.ListUsersByForum(ctx, db.ListUsersByForumParams{
+			ForumSlug: slug,
+			Since:     sinceParam,
+			Limit:     int32(limit),
+		})
 	}
-
 	if err != nil {
-		return nil, fmt.Errorf("select users: %w", err)
+		return nil, nil, fmt.Errorf("select users: %w", err)
 	}
-	defer rows.Close()
 
-	result := make(Users, 0, 1)
-	for rows.Next() {
-		var user User
-		err = rows.Scan(&user.Nickname, &user.FullName, &user.Email, &user.About)
+	if len(rows) == 0 {
+		_, err = s.Cache.ForumBySlug(ctx, slug)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil, ErrNotFoundForum
+		}
 		if err != nil {
-			return nil, fmt.Errorf("scan users %w", err)
+			return nil, nil, fmt.Errorf("select forum: %w", err)
 		}
-
-		result = append(result, &user)
 	}
 
-	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("scan users: %w", err)
+	result := make(Users, 0, len(rows))
+	for _, row := range rows {
+		result = append(result, FromDB(row))
 	}
-	rows.Close()
 
-	if len(result) == 0 {
-		var forumSlug *string
-		err = s.DB.QueryRow(ctx, "SELECT slug FROM forums WHERE slug = $1", slug).Scan(&forumSlug)
-		if forumSlug == nil {
-			return nil, ErrNotFoundForum
-		}
+	var next *Cursor
+	if len(rows) > 0 && len(rows) == limit {
+		next = &Cursor{Nickname: rows[len(rows)-1].Nickname, Desc: page.Desc}
 	}
+	return &result, next, nil
+}
 
-	return &result, nil
+func textOrNull(s *string) pgtype.Text {
+	if s == nil {
+		return pgtype.Text{}
+	}
+	return pgtype.Text{String: *s, Valid: true}
 }
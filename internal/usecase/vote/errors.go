@@ -3,6 +3,7 @@ package vote
 import "errors"
 
 var (
+	ErrNotFoundPost   = errors.New("not found post")
 	ErrNotFoundThread = errors.New("not found thread")
 	ErrNotFoundUser   = errors.New("not found user")
 )
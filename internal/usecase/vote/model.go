@@ -1,6 +1,42 @@
 package vote
 
+import "github.com/viewsharp/technopark-forum/internal/db"
+
 type Vote struct {
-	Nickname *string `json:"nickname"`
-	Voice    *int32  `json:"voice"`
-}
\ No newline at end of file
+	Nickname *string `json:"nickname" validate:"required"`
+	Voice    *int32  `json:"voice" validate:"required,oneof=-1 0 1"`
+}
+
+// FromDB converts a votes row.
+func FromDB(v db.Vote) *Vote {
+	return &Vote{Nickname: &v.UserNn, Voice: &v.Voice.Int32}
+}
+
+type Votes []*Vote
+
+// Page selects one page of a thread's votes by voter nickname. Since and
+// After both continue after a nickname; After is the cursor handed out with
+// the previous page. A non-zero Voice keeps only votes with that voice.
+type Page struct {
+	Desc  bool
+	Voice int32
+	Since string
+	After *Cursor
+	Limit int
+}
+
+// Cursor is the keyset position of the last vote of a page. A user votes on
+// a thread once, so the nickname orders votes without a tie-breaker. Voice
+// ties the cursor to the filter it was issued for.
+type Cursor struct {
+	Nickname string `json:"nickname"`
+	Voice    int32  `json:"voice,omitempty"`
+	Desc     bool   `json:"desc"`
+}
+
+// Change is the caller's voice on the thread before and after a vote, 0
+// meaning no vote.
+type Change struct {
+	Previous int32
+	Current  int32
+}
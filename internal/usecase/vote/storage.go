@@ -7,68 +7,278 @@ import (
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
-)
+	"github.com/jackc/pgx/v5/pgtype"
 
-type DB interface {
-	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
-	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
-	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
-}
+	"github.com/viewsharp/technopark-forum/internal/cache"
+	"github.com/viewsharp/technopark-forum/internal/db"
+)
 
 type Usecase struct {
-	DB DB
+	Queries *db.Queries
+	Cache   *cache.Entities
 }
 
-func (s *Usecase) AddByThreadId(ctx context.Context, vote *Vote, threadId int) error {
-	_, err := s.DB.Exec(
-		ctx,
-		`
-			INSERT INTO votes (thread_id, user_nn, voice) 
-			VALUES ($1, $2, $3) 
-			ON CONFLICT ON CONSTRAINT votes_thread_user_unique 
-			DO UPDATE SET voice = $3
-				WHERE votes.thread_id = (SELECT id FROM threads WHERE id = $1) AND votes.user_nn = $2;`,
-		threadId, vote.Nickname, vote.Voice,
-	)
+func (s *Usecase) AddByThreadId(ctx context.Context, vote *Vote, threadId int) (*Change, error) {
+	return s.add(ctx, vote, int32(threadId))
+}
 
+func (s *Usecase) AddByThreadSlug(ctx context.Context, vote *Vote, threadSlug string) (*Change, error) {
+	threadId, err := s.threadIdBySlug(ctx, threadSlug)
 	if err != nil {
-		var pgErr *pgconn.PgError
-		if errors.As(err, &pgErr) {
-			switch pgErr.Code {
-			case "23502":
-				return ErrNotFoundUser
-			case "23503":
-				return ErrNotFoundThread
+		return nil, err
+	}
+	return s.add(ctx, vote, threadId)
+}
+
+// add stores a voice of -1 or 1 and deletes the vote for 0. The votes
+// triggers move the thread's count by the difference.
+func (s *Usecase) add(ctx context.Context, vote *Vote, threadId int32) (*Change, error) {
+	change := &Change{Current: *vote.Voice}
+
+	var err error
+	if *vote.Voice == 0 {
+		change.Previous, err = s.retract(ctx, *vote.Nickname, threadId)
+	} else {
+		change.Previous, err = s.Queries.UpsertVote(ctx, db.UpsertVoteParams{
+			ThreadID: threadId,
+			UserNn:   *vote.Nickname,
+			Voice:    pgtype.Int4{Int32: *vote.Voice, Valid: true},
+		})
+		if err != nil {
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) {
+				err = mapPgError(pgErr, err)
+			} else {
+				err = fmt.Errorf("insert vote: %w", err)
 			}
 		}
-		return fmt.Errorf("insert vote: %w", err)
 	}
-	return nil
+	if err != nil {
+		return nil, err
+	}
+	return change, nil
 }
 
-func (s *Usecase) AddByThreadSlug(ctx context.Context, vote *Vote, threadSlug string) error {
-	_, err := s.DB.Exec(
-		ctx,
-		`
-			INSERT INTO votes (thread_id, user_nn, voice) 
-			VALUES ((SELECT id FROM threads WHERE slug = $1), $2, $3) 
-			ON CONFLICT ON CONSTRAINT votes_thread_user_unique 
-			DO UPDATE SET voice = $3
-				WHERE votes.thread_id = (SELECT id FROM threads WHERE slug = $1) AND votes.user_nn = $2;`,
-		threadSlug, vote.Nickname, vote.Voice,
-	)
+// retract deletes the user's vote and returns its voice. Nothing to delete
+// is not an error, unless the thread or the user does not exist.
+func (s *Usecase) retract(ctx context.Context, nickname string, threadId int32) (int32, error) {
+	voice, err := s.Queries.DeleteVote(ctx, db.DeleteVoteParams{
+		ThreadID: threadId,
+		UserNn:   nickname,
+	})
+	if err == nil {
+		return voice.Int32, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return 0, fmt.Errorf("delete vote: %w", err)
+	}
 
+	err = s.checkThread(ctx, threadId)
+	if err != nil {
+		return 0, err
+	}
+	_, err = s.Cache.UserByNickname(ctx, nickname)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, ErrNotFoundUser
+	}
 	if err != nil {
-		var pgErr *pgconn.PgError
-		if errors.As(err, &pgErr) {
-			switch pgErr.Code {
-			case "23502":
-				return ErrNotFoundUser
-			case "23503":
-				return ErrNotFoundThread
+		return 0, fmt.Errorf("select user: %w", err)
+	}
+	return 0, nil
+}
+
+// AddByPostId votes on a post the way add votes on a thread. Post scores are
+// not cached, so nothing is invalidated.
+func (s *Usecase) AddByPostId(ctx context.Context, vote *Vote, postId int) (*Change, error) {
+	change := &Change{Current: *vote.Voice}
+
+	var err error
+	if *vote.Voice == 0 {
+		change.Previous, err = s.retractPost(ctx, *vote.Nickname, int32(postId))
+	} else {
+		change.Previous, err = s.Queries.UpsertPostVote(ctx, db.UpsertPostVoteParams{
+			PostID: int32(postId),
+			UserNn: *vote.Nickname,
+			Voice:  *vote.Voice,
+		})
+		if err != nil {
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) {
+				err = mapPgError(pgErr, err)
+			} else {
+				err = fmt.Errorf("insert post vote: %w", err)
 			}
 		}
-		return fmt.Errorf("insert vote: %w", err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return change, nil
+}
+
+func (s *Usecase) retractPost(ctx context.Context, nickname string, postId int32) (int32, error) {
+	voice, err := s.Queries.DeletePostVote(ctx, db.DeletePostVoteParams{
+		PostID: postId,
+		UserNn: nickname,
+	})
+	if err == nil {
+		return voice, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return 0, fmt.Errorf("delete post vote: %w", err)
+	}
+
+	posts, err := s.Queries.ListByID(ctx, []int32{postId})
+	if err != nil {
+		return 0, fmt.Errorf("select post: %w", err)
+	}
+	if len(posts) == 0 {
+		return 0, ErrNotFoundPost
+	}
+	_, err = s.Cache.UserByNickname(ctx, nickname)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, ErrNotFoundUser
+	}
+	if err != nil {
+		return 0, fmt.Errorf("select user: %w", err)
+	}
+	return 0, nil
+}
+
+func (s *Usecase) ByThreadId(ctx context.Context, threadId int, nickname string) (*Vote, error) {
+	return s.get(ctx, int32(threadId), nickname)
+}
+
+func (s *Usecase) ByThreadSlug(ctx context.Context, threadSlug string, nickname string) (*Vote, error) {
+	threadId, err := s.threadIdBySlug(ctx, threadSlug)
+	if err != nil {
+		return nil, err
+	}
+	return s.get(ctx, threadId, nickname)
+}
+
+// get returns the user's vote on the thread, with a voice of 0 if they have
+// not voted.
+func (s *Usecase) get(ctx context.Context, threadId int32, nickname string) (*Vote, error) {
+	row, err := s.Queries.GetVote(ctx, db.GetVoteParams{ThreadID: threadId, UserNn: nickname})
+	if err == nil {
+		return FromDB(row), nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("select vote: %w", err)
+	}
+
+	err = s.checkThread(ctx, threadId)
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.Cache.UserByNickname(ctx, nickname)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFoundUser
+	}
+	if err != nil {
+		return nil, fmt.Errorf("select user: %w", err)
+	}
+
+	var none int32
+	return &Vote{Nickname: &user.Nickname, Voice: &none}, nil
+}
+
+func (s *Usecase) ListByThreadId(ctx context.Context, threadId int, page Page) (*Votes, *Cursor, error) {
+	return s.list(ctx, int32(threadId), page)
+}
+
+func (s *Usecase) ListByThreadSlug(ctx context.Context, threadSlug string, page Page) (*Votes, *Cursor, error) {
+	threadId, err := s.threadIdBySlug(ctx, threadSlug)
+	if err != nil {
+		return nil, nil, err
+	}
+	return s.list(ctx, threadId, page)
+}
+
+// list returns a page of the thread's votes and the cursor of the next one,
+// nil when the page is the last.
+func (s *Usecase) list(ctx context.Context, threadId int32, page Page) (*Votes, *Cursor, error) {
+	since := page.Since
+	if page.After != nil {
+		since = page.After.Nickname
+	}
+	sinceParam := pgtype.Text{String: since, Valid: since != ""}
+	voiceParam := pgtype.Int4{Int32: page.Voice, Valid: page.Voice != 0}
+	limit := page.Limit
+
+	var rows []db.Vote
+	var err error
+	if page.Desc {
+		rows, err = s.Queries.ListVotesByThreadDesc(ctx, db.ListVotesByThreadDescParams{
+			ThreadID: threadId,
+			Voice:    voiceParam,
+			Since:    sinceParam,
+			Limit:    int32(limit),
+		})
+	} else {
+		rows, err = s.Queries.ListVotesByThread(ctx, db.ListVotesByThreadParams{
+			ThreadID: threadId,
+			Voice:    voiceParam,
+			Since:    sinceParam,
+			Limit:    int32(limit),
+		})
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("select votes: %w", err)
+	}
+
+	if len(rows) == 0 {
+		err = s.checkThread(ctx, threadId)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	result := make(Votes, 0, len(rows))
+	for _, row := range rows {
+		result = append(result, FromDB(row))
+	}
+
+	var next *Cursor
+	if len(rows) > 0 && len(rows) == limit {
+		next = &Cursor{Nickname: rows[len(rows)-1].UserNn, Voice: page.Voice, Desc: page.Desc}
+	}
+	return &result, next, nil
+}
+
+func (s *Usecase) threadIdBySlug(ctx context.Context, threadSlug string) (int32, error) {
+	thread, err := s.Cache.ThreadBySlug(ctx, threadSlug)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, ErrNotFoundThread
+	}
+	if err != nil {
+		return 0, fmt.Errorf("select thread: %w", err)
+	}
+	return thread.ID, nil
+}
+
+func (s *Usecase) checkThread(ctx context.Context, threadId int32) error {
+	_, err := s.Cache.ThreadByID(ctx, threadId)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrNotFoundThread
+	}
+	if err != nil {
+		return fmt.Errorf("select thread: %w", err)
 	}
 	return nil
 }
+
+func mapPgError(pgErr *pgconn.PgError, err error) error {
+	switch {
+	case pgErr.Code == "23503" && pgErr.ConstraintName == "votes_thread_id_fkey":
+		return ErrNotFoundThread
+	case pgErr.Code == "23503" && pgErr.ConstraintName == "votes_user_nn_fkey":
+		return ErrNotFoundUser
+	case pgErr.Code == "23503" && pgErr.ConstraintName == "post_votes_post_id_fkey":
+		return ErrNotFoundPost
+	case pgErr.Code == "23503" && pgErr.ConstraintName == "post_votes_user_nn_fkey":
+		return ErrNotFoundUser
+	}
+	return fmt.Errorf("insert vote: %w", err)
+}
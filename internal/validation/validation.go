@@ -0,0 +1,194 @@
+package validation
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Rules are declared in the `validate` struct tag as a comma separated list:
+//
+//	required   the pointer field must be present in the request body
+//	email      the string must look like an e-mail address
+//	slug       the string may contain letters, digits, '-' and '_' and must
+//	           not consist of digits only, so it never collides with an id
+//	max=N      the string must be at most N characters long
+//	oneof=A B  the integer must be one of the space separated values
+//
+// All rules but required are skipped for absent fields.
+
+var (
+	regexEmail = regexp.MustCompile(`^[^@\s]+@[^@\s]+$`)
+	regexSlug  = regexp.MustCompile(`^[\w-]*[A-Za-z_-][\w-]*$`)
+)
+
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Errors lists every offending field of a validated value.
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, fieldErr := range e {
+		messages = append(messages, fieldErr.Field+": "+fieldErr.Message)
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Struct validates a struct or a pointer to one. Field names in the result
+// are taken from the json tags. It returns nil or Errors.
+func Struct(v any) error {
+	errs := appendStruct(nil, "", reflect.ValueOf(v))
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// Slice validates every element of a slice of structs, prefixing field names
+// with the element index.
+func Slice[T any](items []T) error {
+	var errs Errors
+	for i := range items {
+		errs = appendStruct(errs, fmt.Sprintf("[%d].", i), reflect.ValueOf(&items[i]))
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+func appendStruct(errs Errors, prefix string, value reflect.Value) Errors {
+	for value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			return errs
+		}
+		value = value.Elem()
+	}
+
+	for _, field := range fieldsOf(value.Type()) {
+		fieldValue := value.Field(field.index)
+		if fieldValue.Kind() == reflect.Pointer {
+			if fieldValue.IsNil() {
+				if field.required {
+					errs = append(errs, FieldError{Field: prefix + field.name, Message: "is required"})
+				}
+				continue
+			}
+			fieldValue = fieldValue.Elem()
+		}
+
+		for _, check := range field.checks {
+			if message := check(fieldValue); message != "" {
+				errs = append(errs, FieldError{Field: prefix + field.name, Message: message})
+			}
+		}
+	}
+
+	return errs
+}
+
+type check func(value reflect.Value) string
+
+type field struct {
+	index    int
+	name     string
+	required bool
+	checks   []check
+}
+
+var fieldsCache sync.Map // reflect.Type -> []field
+
+func fieldsOf(t reflect.Type) []field {
+	if cached, ok := fieldsCache.Load(t); ok {
+		return cached.([]field)
+	}
+
+	var fields []field
+	for i := 0; i < t.NumField(); i++ {
+		structField := t.Field(i)
+		tag := structField.Tag.Get("validate")
+		if tag == "" {
+			continue
+		}
+
+		f := field{index: i, name: jsonName(structField)}
+		for _, rule := range strings.Split(tag, ",") {
+			name, arg, _ := strings.Cut(rule, "=")
+			switch name {
+			case "required":
+				f.required = true
+			case "email":
+				f.checks = append(f.checks, matches(regexEmail, "must be a valid e-mail address"))
+			case "slug":
+				f.checks = append(f.checks, matches(regexSlug, "may contain only letters, digits, '-' and '_' and must not be numeric"))
+			case "max":
+				f.checks = append(f.checks, maxLength(mustAtoi(arg, structField)))
+			case "oneof":
+				f.checks = append(f.checks, oneOf(strings.Fields(arg), structField))
+			default:
+				panic(fmt.Sprintf("validation: unknown rule %q on %s.%s", name, t.Name(), structField.Name))
+			}
+		}
+		fields = append(fields, f)
+	}
+
+	fieldsCache.Store(t, fields)
+	return fields
+}
+
+func jsonName(structField reflect.StructField) string {
+	name, _, _ := strings.Cut(structField.Tag.Get("json"), ",")
+	if name == "" || name == "-" {
+		return structField.Name
+	}
+	return name
+}
+
+func matches(regex *regexp.Regexp, message string) check {
+	return func(value reflect.Value) string {
+		if !regex.MatchString(value.String()) {
+			return message
+		}
+		return ""
+	}
+}
+
+func maxLength(n int) check {
+	return func(value reflect.Value) string {
+		if utf8.RuneCountInString(value.String()) > n {
+			return fmt.Sprintf("must be at most %d characters long", n)
+		}
+		return ""
+	}
+}
+
+func oneOf(options []string, structField reflect.StructField) check {
+	allowed := make(map[int64]struct{}, len(options))
+	for _, option := range options {
+		allowed[int64(mustAtoi(option, structField))] = struct{}{}
+	}
+	message := "must be one of " + strings.Join(options, ", ")
+
+	return func(value reflect.Value) string {
+		if _, ok := allowed[value.Int()]; !ok {
+			return message
+		}
+		return ""
+	}
+}
+
+func mustAtoi(s string, structField reflect.StructField) int {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		panic(fmt.Sprintf("validation: bad rule argument %q on %s", s, structField.Name))
+	}
+	return n
+}